@@ -0,0 +1,23 @@
+package audit
+import (
+	"context"
+	"log"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+const (
+	EventLoginLockout = "login.lockout"
+)
+var AuditCollection *mongo.Collection = database.UserData(database.Client, "AuditLog")
+func Record(ctx context.Context, event models.AuditEvent) {
+	event.ID = primitive.NewObjectID()
+	if event.Created_At.IsZero() {
+		event.Created_At = time.Now()
+	}
+	if _, err := AuditCollection.InsertOne(ctx, event); err != nil {
+		log.Printf("audit: could not record %s: %v", event.Type, err)
+	}
+}
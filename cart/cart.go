@@ -0,0 +1,124 @@
+package cart
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"ecommerce/models"
+	"ecommerce/money"
+	"ecommerce/variants"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+type LineKey struct {
+	Product_ID primitive.ObjectID
+	SKU        string
+}
+func Key(line models.ProductUser) LineKey {
+	return LineKey{Product_ID: line.Product_ID, SKU: line.Variant_SKU}
+}
+func Quantities(lines []models.ProductUser) map[LineKey]int {
+	quantities := make(map[LineKey]int)
+	for _, line := range lines {
+		quantities[Key(line)]++
+	}
+	return quantities
+}
+func ProductIDs(lines []models.ProductUser) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0)
+	seen := make(map[primitive.ObjectID]bool)
+	for _, line := range lines {
+		if !seen[line.Product_ID] {
+			seen[line.Product_ID] = true
+			ids = append(ids, line.Product_ID)
+		}
+	}
+	return ids
+}
+func lineVariant(product models.Product, sku string) (*models.Variant, bool) {
+	if sku == "" {
+		return nil, len(product.Variants) == 0
+	}
+	variant, ok := variants.Find(product, sku)
+	return &variant, ok
+}
+func Revalidate(lines []models.ProductUser, catalog map[primitive.ObjectID]models.Product) (models.CartView, error) {
+	view := models.CartView{
+		Total:    money.Zero(""),
+		UserCart: make([]models.ProductUser, 0, len(lines)),
+		Warnings: make([]models.CartWarning, 0),
+	}
+	quantities := Quantities(lines)
+	warned := make(map[LineKey]bool)
+	for _, line := range lines {
+		key := Key(line)
+		warning := models.CartWarning{Product_ID: line.Product_ID, Variant_SKU: line.Variant_SKU, Product_Name: line.Product_Name, Old_Price: line.Price, Requested: quantities[key]}
+		product, ok := catalog[line.Product_ID]
+		variant, exists := lineVariant(product, line.Variant_SKU)
+		var current *money.Money
+		if ok && exists {
+			current = variants.Price(product, variant)
+		}
+		if current == nil {
+			if !warned[key] {
+				warned[key] = true
+				warning.Code = models.CartWarningRemoved
+				view.Warnings = append(view.Warnings, warning)
+			}
+			continue
+		}
+		stock := variants.Stock(product, variant)
+		if stock != nil && int64(quantities[key]) > *stock {
+			if !warned[key] {
+				warned[key] = true
+				warning.Code = models.CartWarningOutOfStock
+				warning.Available = stock
+				view.Warnings = append(view.Warnings, warning)
+			}
+			continue
+		}
+		price := *current
+		if price != line.Price && !warned[key] {
+			warned[key] = true
+			warning.Code = models.CartWarningPriceChanged
+			warning.New_Price = &price
+			view.Warnings = append(view.Warnings, warning)
+		}
+		line.Price = price
+		if product.Product_Name != nil {
+			line.Product_Name = product.Product_Name
+		}
+		view.UserCart = append(view.UserCart, line)
+		total, err := view.Total.Add(price)
+		if err != nil {
+			return view, err
+		}
+		view.Total = total
+	}
+	view.Fingerprint = Fingerprint(view.Warnings)
+	return view, nil
+}
+func Fingerprint(warnings []models.CartWarning) string {
+	if len(warnings) == 0 {
+		return ""
+	}
+	entries := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		entry := fmt.Sprintf("%s:%s:%s:%d", warning.Product_ID.Hex(), warning.Code, warning.Old_Price, warning.Requested)
+		if warning.Variant_SKU != "" {
+			entry += fmt.Sprintf(":%s", warning.Variant_SKU)
+		}
+		if warning.New_Price != nil {
+			entry += fmt.Sprintf(":%s", *warning.New_Price)
+		}
+		if warning.Available != nil {
+			entry += fmt.Sprintf(":%d", *warning.Available)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	h := sha256.New()
+	for _, entry := range entries {
+		h.Write([]byte(entry + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,99 @@
+package cart
+import (
+	"testing"
+	"ecommerce/models"
+	"ecommerce/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func usd(amount int64) money.Money {
+	return money.New(amount, "USD")
+}
+func product(id primitive.ObjectID, amount int64, stock *int64) models.Product {
+	price := usd(amount)
+	return models.Product{Product_ID: id, Price: &price, Stock: stock}
+}
+func TestRevalidateUnchangedCart(t *testing.T) {
+	id := primitive.NewObjectID()
+	lines := []models.ProductUser{{Product_ID: id, Price: usd(100)}, {Product_ID: id, Price: usd(100)}}
+	view, err := Revalidate(lines, map[primitive.ObjectID]models.Product{id: product(id, 100, nil)})
+	require.NoError(t, err)
+	assert.Empty(t, view.Warnings)
+	assert.Empty(t, view.Fingerprint)
+	assert.Equal(t, usd(200), view.Total)
+	assert.Len(t, view.UserCart, 2)
+}
+func TestRevalidateReportsChanges(t *testing.T) {
+	repriced := primitive.NewObjectID()
+	removed := primitive.NewObjectID()
+	scarce := primitive.NewObjectID()
+	one := int64(1)
+	lines := []models.ProductUser{
+		{Product_ID: repriced, Price: usd(100)},
+		{Product_ID: repriced, Price: usd(100)},
+		{Product_ID: removed, Price: usd(50)},
+		{Product_ID: scarce, Price: usd(10)},
+		{Product_ID: scarce, Price: usd(10)},
+	}
+	catalog := map[primitive.ObjectID]models.Product{
+		repriced: product(repriced, 120, nil),
+		scarce:   product(scarce, 10, &one),
+	}
+	view, err := Revalidate(lines, catalog)
+	require.NoError(t, err)
+	require.Len(t, view.Warnings, 3)
+	assert.Equal(t, models.CartWarningPriceChanged, view.Warnings[0].Code)
+	assert.Equal(t, usd(120), *view.Warnings[0].New_Price)
+	assert.Equal(t, models.CartWarningRemoved, view.Warnings[1].Code)
+	assert.Equal(t, models.CartWarningOutOfStock, view.Warnings[2].Code)
+	assert.Equal(t, 2, view.Warnings[2].Requested)
+	assert.Equal(t, usd(240), view.Total)
+	assert.Len(t, view.UserCart, 2)
+	assert.NotEmpty(t, view.Fingerprint)
+	again, err := Revalidate(lines, catalog)
+	require.NoError(t, err)
+	assert.Equal(t, view.Fingerprint, again.Fingerprint)
+	catalog[repriced] = product(repriced, 130, nil)
+	again, err = Revalidate(lines, catalog)
+	require.NoError(t, err)
+	assert.NotEqual(t, view.Fingerprint, again.Fingerprint)
+}
+func TestQuantitiesAndProductIDs(t *testing.T) {
+	a := primitive.NewObjectID()
+	b := primitive.NewObjectID()
+	lines := []models.ProductUser{{Product_ID: a}, {Product_ID: b}, {Product_ID: a}}
+	assert.Equal(t, map[LineKey]int{{Product_ID: a}: 2, {Product_ID: b}: 1}, Quantities(lines))
+	assert.Equal(t, []primitive.ObjectID{a, b}, ProductIDs(lines))
+}
+func TestRevalidateVariants(t *testing.T) {
+	id := primitive.NewObjectID()
+	small := usd(90)
+	one := int64(1)
+	tee := product(id, 100, nil)
+	tee.Options = []models.ProductOption{{Name: "size", Values: []string{"S", "M", "L"}}}
+	tee.Variants = []models.Variant{
+		{SKU: "TEE-S", Options: map[string]string{"size": "S"}, Price: &small},
+		{SKU: "TEE-M", Options: map[string]string{"size": "M"}, Stock: &one},
+	}
+	lines := []models.ProductUser{
+		{Product_ID: id, Variant_SKU: "TEE-S", Price: usd(90)},
+		{Product_ID: id, Variant_SKU: "TEE-M", Price: usd(100)},
+		{Product_ID: id, Variant_SKU: "TEE-M", Price: usd(100)},
+		{Product_ID: id, Variant_SKU: "TEE-XL", Price: usd(100)},
+		{Product_ID: id, Price: usd(100)},
+	}
+	view, err := Revalidate(lines, map[primitive.ObjectID]models.Product{id: tee})
+	require.NoError(t, err)
+	require.Len(t, view.Warnings, 3)
+	assert.Equal(t, models.CartWarningOutOfStock, view.Warnings[0].Code)
+	assert.Equal(t, "TEE-M", view.Warnings[0].Variant_SKU)
+	assert.Equal(t, 2, view.Warnings[0].Requested)
+	assert.Equal(t, models.CartWarningRemoved, view.Warnings[1].Code)
+	assert.Equal(t, "TEE-XL", view.Warnings[1].Variant_SKU)
+	assert.Equal(t, models.CartWarningRemoved, view.Warnings[2].Code)
+	assert.Empty(t, view.Warnings[2].Variant_SKU)
+	require.Len(t, view.UserCart, 1)
+	assert.Equal(t, usd(90), view.Total)
+	assert.Equal(t, map[LineKey]int{{Product_ID: id, SKU: "TEE-S"}: 1, {Product_ID: id, SKU: "TEE-M"}: 2, {Product_ID: id, SKU: "TEE-XL"}: 1, {Product_ID: id}: 1}, Quantities(lines))
+}
\ No newline at end of file
@@ -0,0 +1,75 @@
+package config
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+func String(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+func Bool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("invalid boolean for %s: %q, using %v", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+func Int(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("invalid integer for %s: %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+func Duration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("invalid duration for %s: %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+func List(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+func Time(key string, fallback time.Time) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Printf("invalid RFC 3339 time for %s: %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
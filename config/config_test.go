@@ -0,0 +1,44 @@
+package config
+import (
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+func TestString(t *testing.T) {
+	t.Setenv("CONFIG_TEST_STRING", "")
+	assert.Equal(t, "fallback", String("CONFIG_TEST_STRING", "fallback"))
+	t.Setenv("CONFIG_TEST_STRING", "value")
+	assert.Equal(t, "value", String("CONFIG_TEST_STRING", "fallback"))
+}
+func TestBool(t *testing.T) {
+	t.Setenv("CONFIG_TEST_BOOL", "")
+	assert.True(t, Bool("CONFIG_TEST_BOOL", true))
+	t.Setenv("CONFIG_TEST_BOOL", "false")
+	assert.False(t, Bool("CONFIG_TEST_BOOL", true))
+	t.Setenv("CONFIG_TEST_BOOL", "not-a-bool")
+	assert.True(t, Bool("CONFIG_TEST_BOOL", true))
+}
+func TestInt(t *testing.T) {
+	t.Setenv("CONFIG_TEST_INT", "42")
+	assert.Equal(t, 42, Int("CONFIG_TEST_INT", 7))
+	t.Setenv("CONFIG_TEST_INT", "x")
+	assert.Equal(t, 7, Int("CONFIG_TEST_INT", 7))
+}
+func TestDuration(t *testing.T) {
+	t.Setenv("CONFIG_TEST_DURATION", "90s")
+	assert.Equal(t, 90*time.Second, Duration("CONFIG_TEST_DURATION", time.Minute))
+	t.Setenv("CONFIG_TEST_DURATION", "soon")
+	assert.Equal(t, time.Minute, Duration("CONFIG_TEST_DURATION", time.Minute))
+}
+func TestList(t *testing.T) {
+	t.Setenv("CONFIG_TEST_LIST", "")
+	assert.Nil(t, List("CONFIG_TEST_LIST", nil))
+	t.Setenv("CONFIG_TEST_LIST", " 10.0.0.1, ,10.0.0.0/8 ")
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.0/8"}, List("CONFIG_TEST_LIST", nil))
+}
+func TestTime(t *testing.T) {
+	t.Setenv("CONFIG_TEST_TIME", "2026-01-02T03:04:05Z")
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Time("CONFIG_TEST_TIME", time.Time{}).UTC())
+	t.Setenv("CONFIG_TEST_TIME", "yesterday")
+	assert.True(t, Time("CONFIG_TEST_TIME", time.Time{}).IsZero())
+}
@@ -11,7 +11,7 @@ import (
 )
 func AddAddress() gin.HandlerFunc {
     return func(c *gin.Context) {
-        userID := c.Query("id")
+        userID := c.GetString("uid")
         if userID == "" {
             c.Header("Content-Type", "application/json")
             c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
@@ -28,6 +28,10 @@ func AddAddress() gin.HandlerFunc {
             c.JSON(http.StatusNotAcceptable, gin.H{"error": err.Error()})
             return
         }
+        if err = Validate.Struct(address); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
         ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
         defer cancel()
         matchFilter := bson.D{{Key: "$match", Value: bson.D{{Key: "_id", Value: addressID}}}}
@@ -69,7 +73,7 @@ func AddAddress() gin.HandlerFunc {
 }
 func EditHomeAddress() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		user_id := c.Query("id")
+		user_id := c.GetString("uid")
 		if user_id == "" {
 			c.Header("Content-Type", "application/json")
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Invalid"})
@@ -79,15 +83,17 @@ func EditHomeAddress() gin.HandlerFunc {
 		usert_id, err := primitive.ObjectIDFromHex(user_id)
 		if err != nil {
 			c.IndentedJSON(500, err)
+			return
 		}
 		var editaddress models.Address
 		if err := c.BindJSON(&editaddress); err != nil {
 			c.IndentedJSON(http.StatusBadRequest, err.Error())
+			return
 		}
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 		filter := bson.D{primitive.E{Key: "_id", Value: usert_id}}
-		update := bson.D{{Key: "$set", Value: bson.D{primitive.E{Key: "address.0.house_name", Value: editaddress.House}, {Key: "address.0.street_name", Value: editaddress.Street}, {Key: "address.0.city_name", Value: editaddress.City}, {Key: "address.0.pin_code", Value: editaddress.Pincode}}}}
+		update := bson.D{{Key: "$set", Value: bson.D{primitive.E{Key: "address.0.house_name", Value: editaddress.House}, {Key: "address.0.street_name", Value: editaddress.Street}, {Key: "address.0.city_name", Value: editaddress.City}, {Key: "address.0.pin_code", Value: editaddress.Pincode}, {Key: "address.0.country", Value: editaddress.Country}, {Key: "address.0.region", Value: editaddress.Region}}}}
 		_, err = UserCollection.UpdateOne(ctx, filter, update)
 		if err != nil {
 			c.IndentedJSON(500, "Something Went Wrong")
@@ -100,7 +106,7 @@ func EditHomeAddress() gin.HandlerFunc {
 }
 func EditWorkAddress() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		user_id := c.Query("id")
+		user_id := c.GetString("uid")
 		if user_id == "" {
 			c.Header("Content-Type", "application/json")
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Wrong id not provided"})
@@ -110,15 +116,17 @@ func EditWorkAddress() gin.HandlerFunc {
 		usert_id, err := primitive.ObjectIDFromHex(user_id)
 		if err != nil {
 			c.IndentedJSON(500, err)
+			return
 		}
 		var editaddress models.Address
 		if err := c.BindJSON(&editaddress); err != nil {
 			c.IndentedJSON(http.StatusBadRequest, err.Error())
+			return
 		}
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 		filter := bson.D{primitive.E{Key: "_id", Value: usert_id}}
-		update := bson.D{{Key: "$set", Value: bson.D{primitive.E{Key: "address.1.house_name", Value: editaddress.House}, {Key: "address.1.street_name", Value: editaddress.Street}, {Key: "address.1.city_name", Value: editaddress.City}, {Key: "address.1.pin_code", Value: editaddress.Pincode}}}}
+		update := bson.D{{Key: "$set", Value: bson.D{primitive.E{Key: "address.1.house_name", Value: editaddress.House}, {Key: "address.1.street_name", Value: editaddress.Street}, {Key: "address.1.city_name", Value: editaddress.City}, {Key: "address.1.pin_code", Value: editaddress.Pincode}, {Key: "address.1.country", Value: editaddress.Country}, {Key: "address.1.region", Value: editaddress.Region}}}}
 		_, err = UserCollection.UpdateOne(ctx, filter, update)
 		if err != nil {
 			c.IndentedJSON(500, "something Went wrong")
@@ -131,7 +139,7 @@ func EditWorkAddress() gin.HandlerFunc {
 }
 func DeleteAddress() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		user_id := c.Query("id")
+		user_id := c.GetString("uid")
 		if user_id == "" {
 			c.Header("Content-Type", "application/json")
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Invalid Search Index"})
@@ -142,6 +150,7 @@ func DeleteAddress() gin.HandlerFunc {
 		usert_id, err := primitive.ObjectIDFromHex(user_id)
 		if err != nil {
 			c.IndentedJSON(500, "Internal Server Error")
+			return
 		}
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
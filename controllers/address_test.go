@@ -16,10 +16,10 @@ func TestAddAddress(t *testing.T) {
 	defer teardown()
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
-	r.POST("/addaddress", AddAddress())
 	userID := primitive.NewObjectID().Hex()
+	r.POST("/addaddress", asUser(userID), AddAddress())
 	_, _ = mockUserColl.InsertOne(context.Background(), models.User{
-		ID:        primitive.NewObjectID(),
+		ID:        mustObjectID(userID),
 		User_ID:   userID,
 		Address_Details:   []models.Address{},
 	})
@@ -29,7 +29,7 @@ func TestAddAddress(t *testing.T) {
 		City:      stringPtr("Cityville"),
 		Pincode:   stringPtr("12345"),
 	}
-	w := performRequest(r, "POST", "/addaddress?id="+userID, address)
+	w := performRequest(r, "POST", "/addaddress", address)
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Address added successfully")
 }
@@ -38,10 +38,10 @@ func TestEditHomeAddress(t *testing.T) {
 	defer teardown()
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
-	r.PUT("/edithomeaddress", EditHomeAddress())
 	userID := primitive.NewObjectID().Hex()
+	r.PUT("/edithomeaddress", asUser(userID), EditHomeAddress())
 	_, _ = mockUserColl.InsertOne(context.Background(), models.User{
-		ID:        primitive.NewObjectID(),
+		ID:        mustObjectID(userID),
 		User_ID:   userID,
 		Address_Details:   []models.Address{
 			{
@@ -57,7 +57,7 @@ func TestEditHomeAddress(t *testing.T) {
 		City:    stringPtr("Newville"),
 		Pincode: stringPtr("67890"),
 	}
-	w := performRequest(r, "PUT", "/edithomeaddress?id="+userID, updatedAddress)
+	w := performRequest(r, "PUT", "/edithomeaddress", updatedAddress)
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Successfully Updated the Home address")
 }
@@ -66,10 +66,10 @@ func TestEditWorkAddress(t *testing.T) {
 	defer teardown()
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
-	r.PUT("/editworkaddress", EditWorkAddress())
 	userID := primitive.NewObjectID().Hex()
+	r.PUT("/editworkaddress", asUser(userID), EditWorkAddress())
 	_, _ = mockUserColl.InsertOne(context.Background(), models.User{
-		ID:        primitive.NewObjectID(),
+		ID:        mustObjectID(userID),
 		User_ID:   userID,
 		Address_Details:   []models.Address{
 			{
@@ -91,7 +91,7 @@ func TestEditWorkAddress(t *testing.T) {
 		City:    stringPtr("Newtown"),
 		Pincode: stringPtr("12345"),
 	}
-	w := performRequest(r, "PUT", "/editworkaddress?id="+userID, updatedAddress)
+	w := performRequest(r, "PUT", "/editworkaddress", updatedAddress)
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Successfully updated the Work Address")
 }
@@ -100,10 +100,10 @@ func TestDeleteAddress(t *testing.T) {
 	defer teardown()
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
-	r.DELETE("/deleteaddresses", DeleteAddress())
 	userID := primitive.NewObjectID().Hex()
+	r.DELETE("/deleteaddresses", asUser(userID), DeleteAddress())
 	_, _ = mockUserColl.InsertOne(context.Background(), models.User{
-		ID:        primitive.NewObjectID(),
+		ID:        mustObjectID(userID),
 		User_ID:   userID,
 		Address_Details:   []models.Address{
 			{
@@ -113,10 +113,19 @@ func TestDeleteAddress(t *testing.T) {
 				Pincode:stringPtr("98765"),
 			}},
 	})
-	w := performRequest(r, "DELETE", "/deleteaddresses?id="+userID, nil)
+	w := performRequest(r, "DELETE", "/deleteaddresses", nil)
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Successfully Deleted!")
 }
+func asUser(uid string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("uid", uid)
+	}
+}
+func mustObjectID(hex string) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(hex)
+	return id
+}
 func performRequest(r *gin.Engine, method, url string, body interface{}) *httptest.ResponseRecorder {
 	var requestBody *bytes.Reader
 	if body != nil {
@@ -0,0 +1,104 @@
+package controllers
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	generate "ecommerce/tokens"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var APIKeyCollection *mongo.Collection = database.UserData(database.Client, "APIKeys")
+func CreateAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.APIKeyCreate
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		now := time.Now()
+		apikey := models.APIKey{
+			User_ID:    c.GetString("uid"),
+			Name:       request.Name,
+			Scopes:     request.Scopes,
+			Created_At: now,
+		}
+		if request.Expires_In_Days > 0 {
+			expires := now.AddDate(0, 0, request.Expires_In_Days)
+			apikey.Expires_At = &expires
+		}
+		var key string
+		var err error
+		for attempt := 0; attempt < 3; attempt++ {
+			key, apikey.Prefix, apikey.Secret_Hash, err = generate.GenerateAPIKey()
+			if err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate api key"})
+				return
+			}
+			apikey.ID = primitive.NewObjectID()
+			if _, err = APIKeyCollection.InsertOne(ctx, apikey); !mongo.IsDuplicateKeyError(err) {
+				break
+			}
+		}
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create api key"})
+			return
+		}
+		c.JSON(http.StatusCreated, models.APIKeyCreated{APIKey: apikey, Key: key})
+	}
+}
+func ListAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		apikeys := make([]models.APIKey, 0)
+		opts := options.Find().SetSort(bson.M{"created_at": -1})
+		cursor, err := APIKeyCollection.Find(ctx, bson.M{"user_id": c.GetString("uid")}, opts)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list api keys"})
+			return
+		}
+		if err = cursor.All(ctx, &apikeys); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list api keys"})
+			return
+		}
+		c.JSON(http.StatusOK, apikeys)
+	}
+}
+func DeleteAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		result, err := APIKeyCollection.DeleteOne(ctx, bson.M{"_id": keyID, "user_id": c.GetString("uid")})
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete api key"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "API key deleted"})
+	}
+}
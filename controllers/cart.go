@@ -7,6 +7,10 @@ import (
 	"time"
 	"ecommerce/database"
 	"ecommerce/models"
+	"ecommerce/money"
+	"ecommerce/promotions"
+	"ecommerce/shipping"
+	"ecommerce/tax"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -22,6 +26,18 @@ func NewApplication(prodCollection, userCollection *mongo.Collection) *Applicati
 		userCollection: userCollection,
 	}
 }
+func checkoutAdjusters(c *gin.Context) []database.OrderAdjuster {
+	addressID := c.Query("address_id")
+	return []database.OrderAdjuster{
+		addressAdjuster(addressID),
+		currencyAdjuster(displayCurrency(c)),
+		promotionAdjuster(),
+		couponAdjuster(),
+		shippingAdjuster(c.Query("shipping_method"), addressID),
+		taxAdjuster(addressID),
+		invoiceAdjuster(),
+	}
+}
 func (app *Application) AddToCart() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		productQueryID := c.Query("id")
@@ -30,7 +46,7 @@ func (app *Application) AddToCart() gin.HandlerFunc {
 			_ = c.AbortWithError(http.StatusBadRequest, errors.New("product id is empty"))
 			return
 		}
-		userQueryID := c.Query("userID")
+		userQueryID := c.GetString("uid")
 		if userQueryID == "" {
 			log.Println("user id is empty")
 			_ = c.AbortWithError(http.StatusBadRequest, errors.New("user id is empty"))
@@ -44,9 +60,14 @@ func (app *Application) AddToCart() gin.HandlerFunc {
 		}
 		var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		err = database.AddProductToCart(ctx, app.prodCollection, app.userCollection, productID, userQueryID)
+		err = database.AddProductToCart(ctx, app.prodCollection, app.userCollection, productID, userQueryID, cartSelection(c))
+		if isVariantError(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			c.IndentedJSON(http.StatusInternalServerError, err)
+			return
 		}
 		c.IndentedJSON(200, "Successfully Added to the cart")
 	}
@@ -59,10 +80,11 @@ func (app *Application) RemoveItem() gin.HandlerFunc {
 			_ = c.AbortWithError(http.StatusBadRequest, errors.New("product id is empty"))
 			return
 		}
-		userQueryID := c.Query("userID")
+		userQueryID := c.GetString("uid")
 		if userQueryID == "" {
 			log.Println("user id is empty")
 			_ = c.AbortWithError(http.StatusBadRequest, errors.New("UserID is empty"))
+			return
 		}
 		ProductID, err := primitive.ObjectIDFromHex(productQueryID)
 		if err != nil {
@@ -72,7 +94,11 @@ func (app *Application) RemoveItem() gin.HandlerFunc {
 		}
 		var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		err = database.RemoveCartItem(ctx, app.prodCollection, app.userCollection, ProductID, userQueryID)
+		err = database.RemoveCartItem(ctx, app.prodCollection, app.userCollection, ProductID, userQueryID, c.Query("sku"))
+		if isVariantError(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			c.IndentedJSON(http.StatusInternalServerError, err)
 			return
@@ -82,7 +108,7 @@ func (app *Application) RemoveItem() gin.HandlerFunc {
 }
 func GetItemFromCart() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		user_id := c.Query("id")
+		user_id := c.GetString("uid")
 		if user_id == "" {
 			c.Header("Content-Type", "application/json")
 			c.JSON(http.StatusNotFound, gin.H{"error": "invalid id"})
@@ -99,47 +125,88 @@ func GetItemFromCart() gin.HandlerFunc {
 			c.IndentedJSON(500, "not id found")
 			return
 		}
-		filter_match := bson.D{{Key: "$match", Value: bson.D{primitive.E{Key: "_id", Value: usert_id}}}}
-		unwind := bson.D{{Key: "$unwind", Value: bson.D{primitive.E{Key: "path", Value: "$usercart"}}}}
-		grouping := bson.D{{Key: "$group", Value: bson.D{primitive.E{Key: "_id", Value: "$_id"}, {Key: "total", Value: bson.D{primitive.E{Key: "$sum", Value: "$usercart.price"}}}}}}
-		pointcursor, err := UserCollection.Aggregate(ctx, mongo.Pipeline{filter_match, unwind, grouping})
+		discounts, _, err := evaluatePromotions(ctx, filledcart.UserCart, baseConverter())
 		if err != nil {
 			log.Println(err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		subtotal := money.Zero("")
+		for _, line := range filledcart.UserCart {
+			if subtotal, err = subtotal.Add(line.Price); err != nil {
+				log.Println(err)
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
 		}
-		var listing []bson.M
-		if err = pointcursor.All(ctx, &listing); err != nil {
+		total, _, _, err := promotions.Apply(subtotal, discounts)
+		if err != nil {
 			log.Println(err)
 			c.AbortWithStatus(http.StatusInternalServerError)
+			return
 		}
-		for _, json := range listing {
-			c.IndentedJSON(200, json["total"])
-			c.IndentedJSON(200, filledcart.UserCart)
-		}
+		c.IndentedJSON(200, total)
+		c.IndentedJSON(200, filledcart.UserCart)
 		ctx.Done()
 	}
 }
+func (app *Application) GetCart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		view, err := database.RevalidateCart(ctx, app.prodCollection, app.userCollection, c.GetString("uid"), checkoutAdjusters(c)...)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, view)
+	}
+}
 func (app *Application) BuyFromCart() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userQueryID := c.Query("id")
+		userQueryID := c.GetString("uid")
 		if userQueryID == "" {
-			log.Panicln("user id is empty")
+			log.Println("user id is empty")
 			_ = c.AbortWithError(http.StatusBadRequest, errors.New("UserID is empty"))
+			return
 		}
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
-		err := database.BuyItemFromCart(ctx, app.userCollection, userQueryID)
-		if err != nil {
+		if !requireVerifiedEmail(ctx, c, userQueryID) {
+			return
+		}
+		view, err := database.BuyItemFromCart(ctx, app.prodCollection, app.userCollection, userQueryID, c.Query("ack"), checkoutAdjusters(c)...)
+		switch {
+		case errors.Is(err, database.ErrCartChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "warnings": view.Warnings, "fingerprint": view.Fingerprint})
+			return
+		case errors.Is(err, database.ErrCartEmpty):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, database.ErrOutOfStock):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case isCouponError(err):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "coupon": view.Applied_Coupon})
+			return
+		case isCurrencyError(err), errors.Is(err, tax.ErrInvalidRate), errors.Is(err, shipping.ErrUnavailable):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case err != nil:
 			c.IndentedJSON(http.StatusInternalServerError, err)
+			return
 		}
 		c.IndentedJSON(200, "Successfully Placed the order")
 	}
 }
 func (app *Application) InstantBuy() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		UserQueryID := c.Query("userid")
+		UserQueryID := c.GetString("uid")
 		if UserQueryID == "" {
 			log.Println("UserID is empty")
 			_ = c.AbortWithError(http.StatusBadRequest, errors.New("UserID is empty"))
+			return
 		}
 		ProductQueryID := c.Query("pid")
 		if ProductQueryID == "" {
@@ -154,9 +221,21 @@ func (app *Application) InstantBuy() gin.HandlerFunc {
 		}
 		var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		err = database.InstantBuyer(ctx, app.prodCollection, app.userCollection, productID, UserQueryID)
+		if !requireVerifiedEmail(ctx, c, UserQueryID) {
+			return
+		}
+		err = database.InstantBuyer(ctx, app.prodCollection, app.userCollection, productID, UserQueryID, cartSelection(c), addressAdjuster(c.Query("address_id")), currencyAdjuster(displayCurrency(c)), shippingAdjuster(c.Query("shipping_method"), c.Query("address_id")), taxAdjuster(c.Query("address_id")), invoiceAdjuster())
+		if errors.Is(err, database.ErrOutOfStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if isCurrencyError(err) || isVariantError(err) || errors.Is(err, tax.ErrInvalidRate) || errors.Is(err, shipping.ErrUnavailable) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			c.IndentedJSON(http.StatusInternalServerError, err)
+			return
 		}
 		c.IndentedJSON(200, "Successully placed the order")
 	}
@@ -0,0 +1,195 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/taxonomy"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var CategoryCollection *mongo.Collection = database.UserData(database.Client, "Categories")
+func findParentCategory(ctx context.Context, c *gin.Context, parentHex string) (*models.Category, bool) {
+	if parentHex == "" {
+		return nil, true
+	}
+	parentID, _ := primitive.ObjectIDFromHex(parentHex)
+	parent, err := database.FindCategory(ctx, CategoryCollection, bson.M{"_id": parentID})
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "parent category not found"})
+		return nil, false
+	}
+	return &parent, true
+}
+func ListCategories() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		categories, err := database.ListCategories(ctx, CategoryCollection, bson.M{})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, taxonomy.Tree(categories))
+	}
+}
+func ListCategoryProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		category, err := database.FindCategory(ctx, CategoryCollection, bson.M{"slug": c.Param("slug")})
+		if errors.Is(err, database.ErrCategoryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		descendants, err := database.ListCategories(ctx, CategoryCollection, bson.M{"ancestors": category.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		categoryIDs := []primitive.ObjectID{category.ID}
+		for _, descendant := range descendants {
+			categoryIDs = append(categoryIDs, descendant.ID)
+		}
+		products, err := database.CategoryProducts(ctx, ProductCollection, categoryIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		setDisplayPrices(ctx, c, products)
+		c.JSON(http.StatusOK, gin.H{"category": category, "products": products})
+	}
+}
+func CreateCategory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.CategoryCreate
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		slug := request.Slug
+		if slug == "" {
+			slug = request.Name
+		}
+		category := models.Category{Name: request.Name, Slug: taxonomy.Slugify(slug), Position: request.Position}
+		if category.Slug == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": taxonomy.ErrInvalidSlug.Error()})
+			return
+		}
+		parent, ok := findParentCategory(ctx, c, request.Parent_ID)
+		if !ok {
+			return
+		}
+		if parent != nil {
+			category.Parent_ID = &parent.ID
+		}
+		category.Ancestors = taxonomy.AncestorsFor(parent)
+		category, err := database.CreateCategory(ctx, CategoryCollection, category)
+		if errors.Is(err, database.ErrCategorySlugTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, category)
+	}
+}
+func MoveCategory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categoryID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.CategoryMove
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		category, err := database.FindCategory(ctx, CategoryCollection, bson.M{"_id": categoryID})
+		if errors.Is(err, database.ErrCategoryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		parent, ok := findParentCategory(ctx, c, request.Parent_ID)
+		if !ok {
+			return
+		}
+		category, err = database.MoveCategory(ctx, CategoryCollection, category, parent)
+		if errors.Is(err, database.ErrCategoryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, taxonomy.ErrCycle) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, category)
+	}
+}
+func SetProductCategories() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.ProductCategories
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		categoryIDs, err := parseObjectIDs(request.Category_IDs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		categories, err := database.ListCategories(ctx, CategoryCollection, bson.M{"_id": bson.M{"$in": categoryIDs}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(categories) != len(categoryIDs) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": database.ErrCategoryNotFound.Error()})
+			return
+		}
+		err = database.SetProductCategories(ctx, ProductCollection, productID, categoryIDs)
+		if errors.Is(err, database.ErrCantFindProduct) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"product_id": productID, "category_ids": categoryIDs})
+	}
+}
@@ -4,36 +4,59 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+	"ecommerce/config"
 	"ecommerce/database"
+	"ecommerce/loginguard"
+	"ecommerce/middleware"
 	"ecommerce/models"
+	"ecommerce/passwords"
 	generate "ecommerce/tokens"
+	"ecommerce/variants"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"golang.org/x/crypto/bcrypt"
 )
 var UserCollection *mongo.Collection = database.UserData(database.Client, "Users")
 var ProductCollection *mongo.Collection = database.ProductData(database.Client, "Products")
 var Validate = validator.New()
-func HashPassword(password string) string {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+var Passwords = passwords.FromEnv()
+var PasswordPolicy = passwords.PolicyFromEnv()
+func HashPassword(password string) (string, error) {
+	return Passwords.Hash(password)
+}
+func VerifyPassword(userpassword string, givenpassword string) (bool, string) {
+	valid, _, err := Passwords.Verify(userpassword, givenpassword)
 	if err != nil {
-		log.Panic(err)
+		log.Println(err)
+	}
+	if !valid {
+		return false, "Login Or Passowrd is Incorerct"
 	}
-	return string(bytes)
+	return true, ""
 }
-func VerifyPassword(userpassword string, givenpassword string) (bool, string) {
-	err := bcrypt.CompareHashAndPassword([]byte(givenpassword), []byte(userpassword))
-	valid := true
-	msg := ""
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+func verifyUserPassword(user models.User, password string) (bool, string) {
+	if user.Password == nil {
+		return VerifyPassword(password, dummyPasswordHash())
+	}
+	return VerifyPassword(password, *user.Password)
+}
+func rehashPassword(ctx context.Context, userID string, oldhash string, password string) {
+	newhash, err := HashPassword(password)
 	if err != nil {
-		msg = "Login Or Passowrd is Incorerct"
-		valid = false
+		log.Println(err)
+		return
+	}
+	filter := bson.M{"user_id": userID, "password": oldhash}
+	if _, err := UserCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"password": newhash}}); err != nil {
+		log.Println(err)
 	}
-	return valid, msg
 }
 func SignUp() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -49,7 +72,9 @@ func SignUp() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr})
 			return
 		}
-		count, err := UserCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+		email := normalizeEmail(*user.Email)
+		user.Email = &email
+		count, err := UserCollection.CountDocuments(ctx, bson.M{"email": email})
 		if err != nil {
 			log.Panic(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
@@ -57,6 +82,7 @@ func SignUp() gin.HandlerFunc {
 		}
 		if count > 0 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "User already exists"})
+			return
 		}
 		count, err = UserCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
 		defer cancel()
@@ -69,23 +95,36 @@ func SignUp() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Phone is already in use"})
 			return
 		}
-		password := HashPassword(*user.Password)
+		if err := PasswordPolicy.Check(*user.Password, *user.Email, *user.First_Name, *user.Last_Name); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		password, err := HashPassword(*user.Password)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "not created"})
+			return
+		}
 		user.Password = &password
 		user.Created_At, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		user.Updated_At, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		user.ID = primitive.NewObjectID()
 		user.User_ID = user.ID.Hex()
-		token, refreshtoken, _ := generate.TokenGenerator(*user.Email, *user.First_Name, *user.Last_Name, user.User_ID)
-		user.Token = &token
-		user.Refresh_Token = &refreshtoken
+		user.Token = nil
+		user.Refresh_Token = nil
 		user.UserCart = make([]models.ProductUser, 0)
+		user.Wishlist = make([]models.WishlistItem, 0)
 		user.Address_Details = make([]models.Address, 0)
 		user.Order_Status = make([]models.Order, 0)
+		user.Verified_At = nil
 		_, inserterr := UserCollection.InsertOne(ctx, user)
 		if inserterr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "not created"})
 			return
 		}
+		if err := sendVerificationEmail(ctx, user); err != nil {
+			log.Println(err)
+		}
 		defer cancel()
 		c.JSON(http.StatusCreated, "Successfully Signed Up!!")
 	}
@@ -100,25 +139,79 @@ func Login() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err})
 			return
 		}
-		err := UserCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&founduser)
+		if user.Email == nil || user.Password == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
+			return
+		}
+		email := normalizeEmail(*user.Email)
+		emailKey := loginguard.EmailKey(email)
+		ipKey := loginguard.IPKey(c.ClientIP())
+		if !loginAllowed(ctx, c, emailKey, ipKey) {
+			return
+		}
+		err := UserCollection.FindOne(ctx, bson.M{"email": email, "anonymized_at": nil}).Decode(&founduser)
 		defer cancel()
-		if err != nil {
+		if err != nil || founduser.Password == nil {
+			VerifyPassword(*user.Password, dummyPasswordHash())
+			recordLoginFailure(ctx, c, "", emailKey, ipKey)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "login or password incorrect"})
 			return
 		}
-		PasswordIsValid, msg := VerifyPassword(*user.Password, *founduser.Password)
+		PasswordIsValid, needsRehash, err := Passwords.Verify(*user.Password, *founduser.Password)
 		defer cancel()
+		if err != nil {
+			log.Println(err)
+		}
 		if !PasswordIsValid {
+			msg := "Login Or Passowrd is Incorerct"
+			recordLoginFailure(ctx, c, founduser.User_ID, emailKey, ipKey)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			fmt.Println(msg)
 			return
 		}
-		token, refreshToken, _ := generate.TokenGenerator(*founduser.Email, *founduser.First_Name, *founduser.Last_Name, founduser.User_ID)
-		defer cancel()
-		generate.UpdateAllTokens(token, refreshToken, founduser.User_ID)
-		c.JSON(http.StatusFound, founduser)
+		if needsRehash {
+			rehashPassword(ctx, founduser.User_ID, *founduser.Password, *user.Password)
+		}
+		if founduser.Totp_Enabled {
+			challenge, err := generate.ChallengeGenerator(founduser.User_ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "could not issue challenge"})
+				return
+			}
+			c.JSON(http.StatusOK, models.TwoFactorChallenge{Two_Factor_Required: true, Challenge_Token: challenge})
+			return
+		}
+		completeLogin(c, founduser)
 	}
 }
+func completeLogin(c *gin.Context, founduser models.User) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	resetLoginFailures(ctx, founduser)
+	_, token, refreshToken, err := createSession(ctx, c, founduser)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create session"})
+		return
+	}
+	if err := setAuthCookies(c, token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not issue tokens"})
+		return
+	}
+	mergeGuestCart(ctx, c, founduser.User_ID)
+	c.JSON(http.StatusFound, models.LoginResponse{User: models.NewUserResponse(founduser), Token: token, Refresh_Token: refreshToken})
+}
+func setAuthCookies(c *gin.Context, token string) error {
+	if !config.Bool("AUTH_COOKIE_ENABLED", false) {
+		return nil
+	}
+	csrftoken, _, err := generate.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	middleware.SetAuthCookies(c, token, csrftoken, int(generate.DefaultService.AccessTTL.Seconds()))
+	return nil
+}
 func ProductViewerAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -128,8 +221,16 @@ func ProductViewerAdmin() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err := variants.Validate(products); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		products.Product_ID = primitive.NewObjectID()
 		_, anyerr := ProductCollection.InsertOne(ctx, products)
+		if mongo.IsDuplicateKeyError(anyerr) {
+			c.JSON(http.StatusConflict, gin.H{"error": database.ErrSKUTaken.Error()})
+			return
+		}
 		if anyerr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Not Created"})
 			return
@@ -161,6 +262,7 @@ func SearchProduct() gin.HandlerFunc {
 			return
 		}
 		defer cancel()
+		setDisplayPrices(ctx, c, productlist)
 		c.IndentedJSON(200, productlist)
 
 	}
@@ -196,6 +298,7 @@ func SearchProductByQuery() gin.HandlerFunc {
 			return
 		}
 		defer cancel()
+		setDisplayPrices(ctx, c, searchproducts)
 		c.IndentedJSON(200, searchproducts)
 	}
 }
\ No newline at end of file
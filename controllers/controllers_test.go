@@ -4,11 +4,15 @@ import (
 	"context"
 	"net/http"
 	"testing"
+	"time"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"ecommerce/models"
+	"ecommerce/money"
 	"github.com/joho/godotenv"
 )
 var (
@@ -72,7 +76,8 @@ func TestLogin(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
 	r.POST("/login", Login())
-	password := HashPassword("password")
+	password, err := HashPassword("password")
+	assert.NoError(t, err)
 	user := models.User{
 		Email:    stringPtr("test@example.com"),
 		Password: &password,
@@ -84,6 +89,32 @@ func TestLogin(t *testing.T) {
 	}
 	w := performRequest(r, "POST", "/login", loginUser)
 	assert.Equal(t, http.StatusFound, w.Code)
+	assert.NotContains(t, w.Body.String(), `"password"`)
+	assert.NotContains(t, w.Body.String(), password)
+}
+func TestLoginAnonymizedUser(t *testing.T) {
+	setup()
+	defer teardown()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", Login())
+	userID := primitive.NewObjectID()
+	email := "deleted-" + userID.Hex() + "@invalid.invalid"
+	anonymizedAt := time.Now()
+	user := models.User{
+		ID:            userID,
+		User_ID:       userID.Hex(),
+		Email:         &email,
+		Anonymized_At: &anonymizedAt,
+	}
+	_, err := UserCollection.InsertOne(context.Background(), user)
+	assert.NoError(t, err)
+	defer UserCollection.DeleteOne(context.Background(), bson.M{"_id": userID})
+	for _, password := range []string{"password", "dummy-password-for-constant-time-login"} {
+		w := performRequest(r, "POST", "/login", models.User{Email: &email, Password: stringPtr(password)})
+		assert.NotEqual(t, http.StatusFound, w.Code)
+		assert.Contains(t, w.Body.String(), "error")
+	}
 }
 func TestProductViewerAdmin(t *testing.T) {
 	setup()
@@ -93,7 +124,7 @@ func TestProductViewerAdmin(t *testing.T) {
 	r.POST("/product/admin", ProductViewerAdmin())
 	product := models.Product{
 		Product_Name: stringPtr("Sample Product"),
-		Price:        intPtr(100),
+		Price:        moneyPtr(100),
 	}
 	w := performRequest(r, "POST", "/product/admin", product)
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -115,7 +146,7 @@ func TestSearchProduct(t *testing.T) {
 	r.GET("/products", SearchProduct())
 	product := models.Product{
 		Product_Name: stringPtr("Sample Product"),
-		Price:        intPtr(100),
+		Price:        moneyPtr(100),
 	}
 	_, _ = mockProdColl.InsertOne(context.Background(), product)
 	w := performRequest(r, "GET", "/products", nil)
@@ -135,6 +166,7 @@ func TestSearchProductByQuery(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
-func intPtr(i uint64) *uint64 {
-	return &i
+func moneyPtr(amount int64) *money.Money {
+	price := money.New(amount, "")
+	return &price
 }
\ No newline at end of file
@@ -0,0 +1,219 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/promotions"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var CouponCollection *mongo.Collection = database.UserData(database.Client, "Coupons")
+var CouponRedemptionCollection *mongo.Collection = database.UserData(database.Client, "CouponRedemptions")
+var CouponUsageCollection *mongo.Collection = database.UserData(database.Client, "CouponUsage")
+func isCouponError(err error) bool {
+	for _, target := range []error{
+		database.ErrCouponNotFound,
+		promotions.ErrCouponInactive,
+		promotions.ErrCouponNotStarted,
+		promotions.ErrCouponExpired,
+		promotions.ErrCouponUsedUp,
+		promotions.ErrCouponUserLimit,
+		promotions.ErrCouponMinTotal,
+		promotions.ErrCouponNotApplicable,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+func evaluateCoupon(ctx context.Context, code string, checkout *database.Checkout) (models.Coupon, models.DiscountLine, error) {
+	coupon, err := database.FindCoupon(ctx, CouponCollection, code)
+	if err != nil {
+		return coupon, models.DiscountLine{}, err
+	}
+	uses, err := database.CountCouponRedemptions(ctx, CouponRedemptionCollection, coupon.ID, checkout.User.User_ID)
+	if err != nil {
+		return coupon, models.DiscountLine{}, err
+	}
+	converted, err := convertCoupon(orderConverter(checkout.Order), coupon)
+	if err != nil {
+		return coupon, models.DiscountLine{}, err
+	}
+	discount, err := promotions.Evaluate(converted, checkout.Order.Order_Cart, checkout.Catalog, uses, time.Now())
+	return coupon, discount, err
+}
+func couponAdjuster() database.OrderAdjuster {
+	return func(ctx context.Context, checkout *database.Checkout) error {
+		if checkout.User.Applied_Coupon == nil {
+			return nil
+		}
+		coupon, discount, err := evaluateCoupon(ctx, *checkout.User.Applied_Coupon, checkout)
+		if err != nil {
+			if checkout.Preview && isCouponError(err) {
+				checkout.View.Coupon_Error = err.Error()
+				return nil
+			}
+			return err
+		}
+		checkout.Order.Discounts = append(checkout.Order.Discounts, discount)
+		if checkout.Preview {
+			return nil
+		}
+		redemption := models.CouponRedemption{User_ID: checkout.User.User_ID, Order_ID: checkout.Order.Order_ID, Amount: discount.Amount}
+		checkout.OnCommit(func(ctx context.Context) error {
+			var err error
+			redemption, err = database.RedeemCoupon(ctx, CouponCollection, CouponUsageCollection, CouponRedemptionCollection, coupon, redemption)
+			return err
+		}, func(ctx context.Context) error {
+			return database.ReleaseCoupon(ctx, CouponCollection, CouponUsageCollection, CouponRedemptionCollection, coupon, redemption)
+		})
+		return nil
+	}
+}
+func (app *Application) ApplyCoupon() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.CouponApply
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		uid := c.GetString("uid")
+		checkout, err := database.LoadCheckout(ctx, app.prodCollection, app.userCollection, uid)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		coupon, _, err := evaluateCoupon(ctx, request.Code, checkout)
+		if errors.Is(err, database.ErrCouponNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if err = database.SetAppliedCoupon(ctx, app.userCollection, uid, &coupon.Code); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		view, err := database.RevalidateCart(ctx, app.prodCollection, app.userCollection, uid, checkoutAdjusters(c)...)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, view)
+	}
+}
+func (app *Application) RemoveCoupon() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		if err := database.SetAppliedCoupon(ctx, app.userCollection, c.GetString("uid"), nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Coupon removed"})
+	}
+}
+func parseObjectIDs(hexes []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(hexes))
+	seen := make(map[primitive.ObjectID]bool)
+	for _, hex := range hexes {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", hex)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+func CreateCoupon() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.CouponCreate
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if request.Type == models.CouponPercentage && (request.Value < 1 || request.Value > 100) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "percentage coupons need a value between 1 and 100"})
+			return
+		}
+		if request.Type == models.CouponFixed && request.Amount.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fixed coupons need a positive amount"})
+			return
+		}
+		if request.Starts_At != nil && request.Ends_At != nil && !request.Ends_At.After(*request.Starts_At) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be after starts_at"})
+			return
+		}
+		productIDs, err := parseObjectIDs(request.Product_IDs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		categoryIDs, err := parseObjectIDs(request.Category_IDs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		coupon, err := database.CreateCoupon(ctx, CouponCollection, models.Coupon{
+			Code:              request.Code,
+			Type:              request.Type,
+			Value:             request.Value,
+			Amount:            request.Amount,
+			Min_Total:         request.Min_Total,
+			Product_IDs:       productIDs,
+			Category_IDs:      categoryIDs,
+			Max_Uses:          request.Max_Uses,
+			Max_Uses_Per_User: request.Max_Uses_Per_User,
+			Starts_At:         request.Starts_At,
+			Ends_At:           request.Ends_At,
+			Active:            true,
+		})
+		if errors.Is(err, database.ErrCouponExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, coupon)
+	}
+}
+func ListCoupons() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		coupons, err := database.ListCoupons(ctx, CouponCollection)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, coupons)
+	}
+}
@@ -0,0 +1,205 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"ecommerce/database"
+	"ecommerce/exchange"
+	"ecommerce/models"
+	"ecommerce/money"
+	"ecommerce/variants"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var ExchangeRateCollection *mongo.Collection = database.UserData(database.Client, "ExchangeRates")
+func displayCurrency(c *gin.Context) string {
+	currency := exchange.Negotiate(c.Query("currency"), c.Request.Header.Get(exchange.Header), c.Request.Header.Get("Accept-Language"), exchange.Supported(), money.DefaultCurrency())
+	c.Header("Vary", "Accept-Language, "+exchange.Header)
+	c.Header(exchange.Header, currency)
+	return currency
+}
+func converterFor(ctx context.Context, to string) (exchange.Converter, error) {
+	base := money.DefaultCurrency()
+	now := time.Now()
+	if to == base {
+		return exchange.NewConverter(nil, base, to, now)
+	}
+	rates, err := database.EffectiveExchangeRates(ctx, ExchangeRateCollection, base, to, now)
+	if err != nil {
+		return exchange.Converter{Base: base, To: to}, err
+	}
+	return exchange.NewConverter(rates, base, to, now)
+}
+func setDisplayPrices(ctx context.Context, c *gin.Context, products []models.Product) {
+	converter, err := converterFor(ctx, displayCurrency(c))
+	if err != nil {
+		return
+	}
+	for i := range products {
+		if products[i].Price == nil {
+			continue
+		}
+		if price, err := converter.Price(products[i], *products[i].Price); err == nil {
+			products[i].Display_Price = &price
+		}
+	}
+}
+func orderConverter(order models.Order) exchange.Converter {
+	return exchange.Converter{Base: money.DefaultCurrency(), To: order.Currency, Rate: models.ExchangeRate{Rate: order.Exchange_Rate}, Found: true}
+}
+func baseConverter() exchange.Converter {
+	return orderConverter(models.Order{Currency: money.DefaultCurrency(), Exchange_Rate: "1"})
+}
+func convertCoupon(converter exchange.Converter, coupon models.Coupon) (models.Coupon, error) {
+	var err error
+	if coupon.Min_Total, err = converter.Money(coupon.Min_Total); err != nil {
+		return coupon, err
+	}
+	coupon.Amount, err = converter.Money(coupon.Amount)
+	return coupon, err
+}
+func convertPromotionRules(converter exchange.Converter, rules []models.PromotionRule) ([]models.PromotionRule, error) {
+	converted := make([]models.PromotionRule, 0, len(rules))
+	for _, rule := range rules {
+		var err error
+		if rule.Bundle_Price, err = converter.Money(rule.Bundle_Price); err != nil {
+			return nil, err
+		}
+		tiers := make([]models.PromotionTier, 0, len(rule.Tiers))
+		for _, tier := range rule.Tiers {
+			if tier.Min_Total, err = converter.Money(tier.Min_Total); err != nil {
+				return nil, err
+			}
+			tiers = append(tiers, tier)
+		}
+		rule.Tiers = tiers
+		converted = append(converted, rule)
+	}
+	return converted, nil
+}
+func convertShippingMethods(converter exchange.Converter, methods []models.ShippingMethod) ([]models.ShippingMethod, error) {
+	converted := make([]models.ShippingMethod, 0, len(methods))
+	for _, method := range methods {
+		var err error
+		if method.Rate, err = converter.Money(method.Rate); err != nil {
+			return nil, err
+		}
+		if method.Free_Over, err = converter.Money(method.Free_Over); err != nil {
+			return nil, err
+		}
+		tiers := make([]models.WeightTier, 0, len(method.Weight_Tiers))
+		for _, tier := range method.Weight_Tiers {
+			if tier.Rate, err = converter.Money(tier.Rate); err != nil {
+				return nil, err
+			}
+			tiers = append(tiers, tier)
+		}
+		method.Weight_Tiers = tiers
+		converted = append(converted, method)
+	}
+	return converted, nil
+}
+func currencyAdjuster(to string) database.OrderAdjuster {
+	return func(ctx context.Context, checkout *database.Checkout) error {
+		order := &checkout.Order
+		converter, err := converterFor(ctx, to)
+		if err != nil {
+			if checkout.Preview {
+				converter, err = converterFor(ctx, money.DefaultCurrency())
+			}
+			if err != nil {
+				return err
+			}
+		}
+		lines := make([]models.ProductUser, 0, len(order.Order_Cart))
+		subtotal := money.Zero(converter.To)
+		for _, line := range order.Order_Cart {
+			product := checkout.Catalog[line.Product_ID]
+			if variant, ok := variants.Find(product, line.Variant_SKU); ok && variant.Price != nil {
+				line.Price, err = converter.Money(line.Price)
+			} else {
+				line.Price, err = converter.Price(product, line.Price)
+			}
+			if err != nil {
+				return err
+			}
+			if subtotal, err = subtotal.Add(line.Price); err != nil {
+				return err
+			}
+			lines = append(lines, line)
+		}
+		effective := converter.Rate.Effective_At
+		order.Order_Cart = lines
+		order.Subtotal = subtotal
+		order.Currency = converter.To
+		order.Exchange_Rate = converter.Rate.Rate
+		order.Rate_Effective_At = &effective
+		return nil
+	}
+}
+func CreateExchangeRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.ExchangeRateCreate
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if _, err := exchange.ParseRate(request.Rate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rate := models.ExchangeRate{
+			Base:  strings.ToUpper(request.Base),
+			Quote: strings.ToUpper(request.Quote),
+			Rate:  request.Rate,
+		}
+		if rate.Base == "" {
+			rate.Base = money.DefaultCurrency()
+		}
+		if rate.Base != money.DefaultCurrency() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "base currency must be " + money.DefaultCurrency()})
+			return
+		}
+		if rate.Base == rate.Quote {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "base and quote currencies must differ"})
+			return
+		}
+		if request.Effective_At != nil {
+			rate.Effective_At = *request.Effective_At
+		}
+		rate, err := database.CreateExchangeRate(ctx, ExchangeRateCollection, rate)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, rate)
+	}
+}
+func ListExchangeRates() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		filter := bson.M{}
+		if quote := c.Query("quote"); quote != "" {
+			filter["quote"] = strings.ToUpper(quote)
+		}
+		rates, err := database.ListExchangeRates(ctx, ExchangeRateCollection, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rates)
+	}
+}
+func isCurrencyError(err error) bool {
+	return errors.Is(err, exchange.ErrNoRate) || errors.Is(err, exchange.ErrInvalidRate) || errors.Is(err, money.ErrCurrencyMismatch)
+}
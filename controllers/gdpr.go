@@ -0,0 +1,148 @@
+package controllers
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"ecommerce/audit"
+	"ecommerce/gdpr"
+	"ecommerce/guestcart"
+	"ecommerce/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+func ExportData() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "zip" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or zip"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		uid := c.GetString("uid")
+		var guestCarts []primitive.ObjectID
+		if cartID, err := CartTokens.Verify(c.Request.Header.Get(guestcart.Header)); err == nil {
+			guestCarts = append(guestCarts, cartID)
+		}
+		export, err := gdpr.Export(ctx, uid, guestCarts...)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not export data"})
+			return
+		}
+		audit.Record(ctx, models.AuditEvent{Type: gdpr.EventExport, User_ID: uid, Actor: "user:" + uid, IP: c.ClientIP(), Details: map[string]interface{}{"format": format}})
+		filename := fmt.Sprintf("export-%s-%s.%s", uid, export.Generated_At.Format("20060102"), format)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Header("Cache-Control", "no-store")
+		if format == "zip" {
+			c.Status(http.StatusOK)
+			c.Header("Content-Type", "application/zip")
+			if err := gdpr.WriteZip(c.Writer, export); err != nil {
+				log.Println(err)
+			}
+			return
+		}
+		body, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not export data"})
+			return
+		}
+		c.Data(http.StatusOK, "application/json", body)
+	}
+}
+func RequestAccountDeletion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		uid := c.GetString("uid")
+		var request models.AccountDeletion
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		var founduser models.User
+		err := UserCollection.FindOne(ctx, bson.M{"user_id": uid}).Decode(&founduser)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if founduser.Delete_After != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "account deletion is already scheduled", "delete_after": founduser.Delete_After})
+			return
+		}
+		PasswordIsValid, msg := verifyUserPassword(founduser, request.Password)
+		if !PasswordIsValid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
+		}
+		due, err := gdpr.RequestDeletion(ctx, uid)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not schedule account deletion"})
+			return
+		}
+		if err := revokeSessions(ctx, uid, ""); err != nil {
+			log.Println(err)
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "Account deletion scheduled, log in and cancel before the deadline to keep your account", "delete_after": due})
+	}
+}
+func CancelAccountDeletion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		cancelled, err := gdpr.CancelDeletion(ctx, c.GetString("uid"))
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not cancel account deletion"})
+			return
+		}
+		if !cancelled {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no account deletion is scheduled"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+	}
+}
+func ListAuditEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		filter := bson.M{}
+		if userID := c.Query("user_id"); userID != "" {
+			filter["user_id"] = userID
+		}
+		if eventType := c.Query("type"); eventType != "" {
+			filter["type"] = eventType
+		}
+		limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		events := make([]models.AuditEvent, 0)
+		cursor, err := audit.AuditCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit))
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list audit events"})
+			return
+		}
+		if err = cursor.All(ctx, &events); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list audit events"})
+			return
+		}
+		c.JSON(http.StatusOK, events)
+	}
+}
@@ -0,0 +1,138 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/guestcart"
+	"ecommerce/money"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var GuestCartCollection *mongo.Collection = database.UserData(database.Client, "GuestCarts")
+var CartTokens *guestcart.Signer
+func guestCartTTL() time.Duration {
+	return config.Duration("GUEST_CART_TTL", 30*24*time.Hour)
+}
+func guestCartID(c *gin.Context) (primitive.ObjectID, bool) {
+	token := c.Request.Header.Get(guestcart.Header)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing " + guestcart.Header + " header"})
+		return primitive.NilObjectID, false
+	}
+	cartID, err := CartTokens.Verify(token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return primitive.NilObjectID, false
+	}
+	return cartID, true
+}
+func guestCartError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, database.ErrCantFindGuestCart), errors.Is(err, database.ErrCantFindProduct):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case isVariantError(err):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+func (app *Application) AddToGuestCart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		cartID := primitive.NilObjectID
+		if c.Request.Header.Get(guestcart.Header) != "" {
+			var ok bool
+			if cartID, ok = guestCartID(c); !ok {
+				return
+			}
+			if _, err := database.GetGuestCart(ctx, GuestCartCollection, cartID); err != nil {
+				cartID = primitive.NilObjectID
+			}
+		}
+		if cartID.IsZero() {
+			cart, err := database.CreateGuestCart(ctx, GuestCartCollection, guestCartTTL())
+			if err != nil {
+				guestCartError(c, err)
+				return
+			}
+			cartID = cart.ID
+		}
+		err = database.AddProductToGuestCart(ctx, app.prodCollection, GuestCartCollection, productID, cartSelection(c), cartID, guestCartTTL())
+		if err != nil {
+			guestCartError(c, err)
+			return
+		}
+		token := CartTokens.Sign(cartID)
+		c.Header(guestcart.Header, token)
+		c.JSON(http.StatusOK, gin.H{"message": "Successfully Added to the cart", "cart_token": token})
+	}
+}
+func RemoveFromGuestCart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+			return
+		}
+		cartID, ok := guestCartID(c)
+		if !ok {
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		if err := database.RemoveGuestCartItem(ctx, ProductCollection, GuestCartCollection, productID, c.Query("sku"), cartID); err != nil {
+			guestCartError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Successfully removed from cart"})
+	}
+}
+func GetGuestCart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID, ok := guestCartID(c)
+		if !ok {
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		cart, err := database.GetGuestCart(ctx, GuestCartCollection, cartID)
+		if err != nil {
+			guestCartError(c, err)
+			return
+		}
+		total := money.Zero("")
+		for _, line := range cart.UserCart {
+			if total, err = total.Add(line.Price); err != nil {
+				guestCartError(c, err)
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"total": total, "usercart": cart.UserCart, "expires_at": cart.Expires_At})
+	}
+}
+func mergeGuestCart(ctx context.Context, c *gin.Context, userID string) {
+	token := c.Request.Header.Get(guestcart.Header)
+	if token == "" {
+		return
+	}
+	cartID, err := CartTokens.Verify(token)
+	if err != nil {
+		return
+	}
+	err = database.MergeGuestCart(ctx, GuestCartCollection, UserCollection, cartID, userID, guestcart.Strategy())
+	if err != nil && !errors.Is(err, database.ErrCantFindGuestCart) {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,159 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"ecommerce/database"
+	"ecommerce/invoicing"
+	"ecommerce/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var InvoiceCollection *mongo.Collection = database.UserData(database.Client, "Invoices")
+func invoiceAdjuster() database.OrderAdjuster {
+	return func(ctx context.Context, checkout *database.Checkout) error {
+		if checkout.Preview {
+			return nil
+		}
+		var issued models.Invoice
+		checkout.OnCommit(func(ctx context.Context) error {
+			invoice, err := invoicing.FromOrder(checkout.User, checkout.Order)
+			if err != nil {
+				return err
+			}
+			invoice.Issued_At = checkout.Order.Orderered_At.UTC()
+			issued, err = database.IssueInvoice(ctx, InvoiceCollection, invoice)
+			return err
+		}, func(ctx context.Context) error {
+			return database.DeleteInvoice(ctx, InvoiceCollection, issued.ID)
+		})
+		return nil
+	}
+}
+func customerOrder(ctx context.Context, c *gin.Context) (models.User, models.Order, bool) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return models.User{}, models.Order{}, false
+	}
+	user, order, err := database.FindOrder(ctx, UserCollection, orderID)
+	if err != nil || user.ID.Hex() != c.GetString("uid") {
+		c.JSON(http.StatusNotFound, gin.H{"error": database.ErrOrderNotFound.Error()})
+		return user, order, false
+	}
+	return user, order, true
+}
+func renderInvoice(c *gin.Context, invoice models.Invoice) {
+	document, err := invoicing.Render(invoice, invoicing.DefaultSeller())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="`+invoice.Number+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", document)
+}
+func GetInvoicePDF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		_, order, ok := customerOrder(ctx, c)
+		if !ok {
+			return
+		}
+		invoice, err := database.FindOrderInvoice(ctx, InvoiceCollection, order.Order_ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		renderInvoice(c, invoice)
+	}
+}
+func ListOrderCreditNotes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		_, order, ok := customerOrder(ctx, c)
+		if !ok {
+			return
+		}
+		notes, err := database.ListCreditNotes(ctx, InvoiceCollection, order.Order_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, notes)
+	}
+}
+func GetCreditNotePDF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		_, order, ok := customerOrder(ctx, c)
+		if !ok {
+			return
+		}
+		note, err := database.FindInvoiceByNumber(ctx, InvoiceCollection, strings.TrimSuffix(c.Param("number"), ".pdf"))
+		if err != nil || note.Kind != models.InvoiceKindCreditNote || note.Order_ID != order.Order_ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": database.ErrInvoiceNotFound.Error()})
+			return
+		}
+		renderInvoice(c, note)
+	}
+}
+func CreateCreditNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.CreditNoteCreate
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		invoice, err := database.FindOrderInvoice(ctx, InvoiceCollection, orderID)
+		if errors.Is(err, database.ErrInvoiceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		credits, err := database.ListCreditNotes(ctx, InvoiceCollection, orderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		note, err := invoicing.CreditNote(invoice, credits, request.Amount, request.Reason)
+		if errors.Is(err, invoicing.ErrNothingToCredit) || errors.Is(err, invoicing.ErrCreditTooLarge) || errors.Is(err, invoicing.ErrInvalidCredit) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if err == nil {
+			err = database.ReserveCredit(ctx, InvoiceCollection, invoice, credits, note.Total)
+		}
+		if errors.Is(err, invoicing.ErrCreditTooLarge) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err == nil {
+			if note, err = database.IssueInvoice(ctx, InvoiceCollection, note); err != nil {
+				database.ReleaseCredit(ctx, InvoiceCollection, invoice, note.Total)
+			}
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, note)
+	}
+}
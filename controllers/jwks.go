@@ -0,0 +1,12 @@
+package controllers
+import (
+	"net/http"
+	generate "ecommerce/tokens"
+	"github.com/gin-gonic/gin"
+)
+func JWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, generate.Keys.JWKS())
+	}
+}
@@ -0,0 +1,72 @@
+package controllers
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"ecommerce/audit"
+	"ecommerce/database"
+	"ecommerce/loginguard"
+	"ecommerce/models"
+	"github.com/gin-gonic/gin"
+)
+var LoginGuard = loginguard.New(database.UserData(database.Client, "LoginAttempts"))
+var dummyHashOnce sync.Once
+var dummyHash string
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		var err error
+		dummyHash, err = HashPassword("dummy-password-for-constant-time-login")
+		if err != nil {
+			log.Println(err)
+		}
+	})
+	return dummyHash
+}
+func loginAllowed(ctx context.Context, c *gin.Context, keys ...string) bool {
+	remaining, err := LoginGuard.Locked(ctx, keys...)
+	if err != nil {
+		log.Println(err)
+		return true
+	}
+	if remaining > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed login attempts, try again later"})
+		return false
+	}
+	return true
+}
+func recordLoginFailure(ctx context.Context, c *gin.Context, userID string, accountKey string, ipKey string) {
+	thresholds := map[string]int{accountKey: LoginGuard.MaxAttempts, ipKey: LoginGuard.IPMaxAttempts}
+	for _, key := range []string{accountKey, ipKey} {
+		attempt, lockout, err := LoginGuard.Fail(ctx, key, thresholds[key])
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if lockout > 0 {
+			audit.Record(ctx, models.AuditEvent{
+				Type:    audit.EventLoginLockout,
+				User_ID: userID,
+				IP:      c.ClientIP(),
+				Details: map[string]interface{}{"key": key, "failures": attempt.Failures, "locked_for": lockout.String()},
+			})
+		}
+	}
+}
+func resetLoginFailures(ctx context.Context, founduser models.User) {
+	keys := []string{loginguard.UserKey(founduser.User_ID)}
+	if founduser.Email != nil {
+		keys = append(keys, loginguard.EmailKey(normalizeEmail(*founduser.Email)))
+	}
+	for _, key := range keys {
+		if err := LoginGuard.Reset(ctx, key); err != nil {
+			log.Println(err)
+		}
+	}
+}
+func init() {
+	go dummyPasswordHash()
+}
@@ -0,0 +1,170 @@
+package controllers
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/mailer"
+	"ecommerce/models"
+	generate "ecommerce/tokens"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var UserTokenCollection *mongo.Collection = database.UserData(database.Client, "UserTokens")
+var Mail mailer.Mailer = mailer.FromEnv()
+const passwordResetTTL = time.Hour
+func appURL() string {
+	return config.String("APP_URL", "http://localhost:8000")
+}
+func issueUserToken(ctx context.Context, userID string, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	_, err := UserTokenCollection.UpdateMany(ctx, bson.M{"user_id": userID, "purpose": purpose, "used_at": nil}, bson.M{"$set": bson.M{"used_at": now}})
+	if err != nil {
+		return "", err
+	}
+	rawtoken, hashedtoken, err := generate.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	usertoken := models.UserToken{
+		ID:         primitive.NewObjectID(),
+		User_ID:    userID,
+		Purpose:    purpose,
+		Token_Hash: hashedtoken,
+		Created_At: now,
+		Expires_At: now.Add(ttl),
+	}
+	if _, err = UserTokenCollection.InsertOne(ctx, usertoken); err != nil {
+		return "", err
+	}
+	return rawtoken, nil
+}
+func userTokenFilter(rawtoken string, purpose string, now time.Time) bson.M {
+	return bson.M{
+		"token_hash": generate.HashOpaqueToken(rawtoken),
+		"purpose":    purpose,
+		"used_at":    nil,
+		"expires_at": bson.M{"$gt": now},
+	}
+}
+func findUserToken(ctx context.Context, rawtoken string, purpose string) (models.UserToken, error) {
+	var usertoken models.UserToken
+	err := UserTokenCollection.FindOne(ctx, userTokenFilter(rawtoken, purpose, time.Now())).Decode(&usertoken)
+	return usertoken, err
+}
+func consumeUserToken(ctx context.Context, rawtoken string, purpose string) (models.UserToken, error) {
+	var usertoken models.UserToken
+	now := time.Now()
+	err := UserTokenCollection.FindOneAndUpdate(ctx, userTokenFilter(rawtoken, purpose, now), bson.M{"$set": bson.M{"used_at": now}}).Decode(&usertoken)
+	return usertoken, err
+}
+func ForgotPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.ForgotPasswordRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		accepted := gin.H{"message": "If an account exists for this email, a reset link has been sent"}
+		var founduser models.User
+		err := UserCollection.FindOne(ctx, bson.M{"email": normalizeEmail(request.Email), "anonymized_at": nil}).Decode(&founduser)
+		if err != nil {
+			if err != mongo.ErrNoDocuments {
+				log.Println(err)
+			}
+			c.JSON(http.StatusAccepted, accepted)
+			return
+		}
+		go sendPasswordReset(founduser.User_ID, *founduser.Email)
+		c.JSON(http.StatusAccepted, accepted)
+	}
+}
+func sendPasswordReset(userID string, email string) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	rawtoken, err := issueUserToken(ctx, userID, models.PurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	err = Mail.Send(mailer.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Use the link below to reset your password. It expires in %s.\n\n%s/reset-password?token=%s", passwordResetTTL, appURL(), rawtoken),
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+func ResetPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.ResetPasswordRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		usertoken, err := findUserToken(ctx, request.Token, models.PurposePasswordReset)
+		if err != nil {
+			if err != mongo.ErrNoDocuments {
+				log.Println(err)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reset token is invalid or expired"})
+			return
+		}
+		var founduser models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"user_id": usertoken.User_ID, "anonymized_at": nil}).Decode(&founduser); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reset token is invalid or expired"})
+			return
+		}
+		if err := PasswordPolicy.Check(request.Password, *founduser.Email, *founduser.First_Name, *founduser.Last_Name); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := consumeUserToken(ctx, request.Token, models.PurposePasswordReset); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reset token is invalid or expired"})
+			return
+		}
+		password, err := HashPassword(request.Password)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update password"})
+			return
+		}
+		now := time.Now()
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "password", Value: password},
+			{Key: "updated_at", Value: now},
+			{Key: "tokens_revoked_at", Value: now},
+		}}}
+		_, err = UserCollection.UpdateOne(ctx, bson.M{"user_id": usertoken.User_ID}, update)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update password"})
+			return
+		}
+		if err := revokeSessions(ctx, usertoken.User_ID, ""); err != nil {
+			log.Println(err)
+		}
+		if err := database.RevokeAPIKeys(ctx, APIKeyCollection, usertoken.User_ID); err != nil {
+			log.Println(err)
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+	}
+}
@@ -0,0 +1,128 @@
+package controllers
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var returnUpdated = options.FindOneAndUpdate().SetReturnDocument(options.After)
+func GetProfile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var founduser models.User
+		err := UserCollection.FindOne(ctx, bson.M{"user_id": c.GetString("uid")}).Decode(&founduser)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusOK, models.NewUserResponse(founduser))
+	}
+}
+func UpdateProfile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		uid := c.GetString("uid")
+		var update models.ProfileUpdate
+		if err := c.BindJSON(&update); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(update); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		updateobj := bson.D{{Key: "updated_at", Value: time.Now()}}
+		if update.First_Name != nil {
+			updateobj = append(updateobj, bson.E{Key: "first_name", Value: update.First_Name})
+		}
+		if update.Last_Name != nil {
+			updateobj = append(updateobj, bson.E{Key: "last_name", Value: update.Last_Name})
+		}
+		if update.Phone != nil {
+			count, err := UserCollection.CountDocuments(ctx, bson.M{"phone": update.Phone, "user_id": bson.M{"$ne": uid}})
+			if err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+				return
+			}
+			if count > 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Phone is already in use"})
+				return
+			}
+			updateobj = append(updateobj, bson.E{Key: "phone", Value: update.Phone})
+		}
+		var founduser models.User
+		err := UserCollection.FindOneAndUpdate(ctx, bson.M{"user_id": uid}, bson.D{{Key: "$set", Value: updateobj}}, returnUpdated).Decode(&founduser)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusOK, models.NewUserResponse(founduser))
+	}
+}
+func ChangePassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		uid := c.GetString("uid")
+		var change models.PasswordChange
+		if err := c.BindJSON(&change); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(change); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		var founduser models.User
+		err := UserCollection.FindOne(ctx, bson.M{"user_id": uid}).Decode(&founduser)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		PasswordIsValid, msg := verifyUserPassword(founduser, change.Current_Password)
+		if !PasswordIsValid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
+		}
+		if err := PasswordPolicy.Check(change.New_Password, *founduser.Email, *founduser.First_Name, *founduser.Last_Name); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		password, err := HashPassword(change.New_Password)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update password"})
+			return
+		}
+		now := time.Now()
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "password", Value: password},
+			{Key: "updated_at", Value: now},
+			{Key: "tokens_revoked_at", Value: now},
+		}}}
+		_, err = UserCollection.UpdateOne(ctx, bson.M{"user_id": uid}, update)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update password"})
+			return
+		}
+		if err := revokeSessions(ctx, uid, c.GetString("sid")); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not revoke other sessions"})
+			return
+		}
+		if err := database.RevokeAPIKeys(ctx, APIKeyCollection, uid); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not revoke api keys"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+	}
+}
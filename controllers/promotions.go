@@ -0,0 +1,185 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"ecommerce/database"
+	"ecommerce/exchange"
+	"ecommerce/models"
+	"ecommerce/promotions"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var PromotionRuleCollection *mongo.Collection = database.UserData(database.Client, "PromotionRules")
+func evaluatePromotions(ctx context.Context, lines []models.ProductUser, converter exchange.Converter) ([]models.DiscountLine, []models.RuleResult, error) {
+	rules, err := database.ListPromotionRules(ctx, PromotionRuleCollection, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rules, err = convertPromotionRules(converter, rules); err != nil {
+		return nil, nil, err
+	}
+	return promotions.EvaluateRules(rules, lines, time.Now())
+}
+func promotionAdjuster() database.OrderAdjuster {
+	return func(ctx context.Context, checkout *database.Checkout) error {
+		discounts, _, err := evaluatePromotions(ctx, checkout.Order.Order_Cart, orderConverter(checkout.Order))
+		if err != nil {
+			return err
+		}
+		checkout.Order.Discounts = append(checkout.Order.Discounts, discounts...)
+		return nil
+	}
+}
+func (app *Application) ExplainPromotions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		view, err := database.RevalidateCart(ctx, app.prodCollection, app.userCollection, c.GetString("uid"))
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		discounts, results, err := evaluatePromotions(ctx, view.UserCart, baseConverter())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		total, discount, _, err := promotions.Apply(view.Total, discounts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, models.PromotionExplain{Subtotal: view.Total, Discount: discount, Total: total, Rules: results})
+	}
+}
+func bindPromotionRule(c *gin.Context) (models.PromotionRule, bool) {
+	var request models.PromotionRuleInput
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.PromotionRule{}, false
+	}
+	if validationErr := Validate.Struct(request); validationErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+		return models.PromotionRule{}, false
+	}
+	productIDs, err := parseObjectIDs(request.Product_IDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.PromotionRule{}, false
+	}
+	rule := models.PromotionRule{
+		Name:         request.Name,
+		Type:         request.Type,
+		Priority:     request.Priority,
+		Exclusive:    request.Exclusive,
+		Active:       request.Active == nil || *request.Active,
+		Product_IDs:  productIDs,
+		Buy_Quantity: request.Buy_Quantity,
+		Get_Quantity: request.Get_Quantity,
+		Bundle_Price: request.Bundle_Price,
+		Tiers:        request.Tiers,
+		Starts_At:    request.Starts_At,
+		Ends_At:      request.Ends_At,
+	}
+	var problem string
+	switch rule.Type {
+	case models.RuleBuyXGetY:
+		if rule.Buy_Quantity < 1 || rule.Get_Quantity < 1 {
+			problem = "buy_x_get_y rules need buy_quantity and get_quantity"
+		}
+	case models.RuleBundle:
+		if len(rule.Product_IDs) < 2 {
+			problem = "bundle rules need at least two products"
+		}
+	case models.RuleTiered:
+		if len(rule.Tiers) == 0 {
+			problem = "tiered rules need at least one tier"
+		}
+	}
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return rule, false
+	}
+	return rule, true
+}
+func CreatePromotionRule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		rule, ok := bindPromotionRule(c)
+		if !ok {
+			return
+		}
+		rule, err := database.CreatePromotionRule(ctx, PromotionRuleCollection, rule)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+func ListPromotionRules() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		rules, err := database.ListPromotionRules(ctx, PromotionRuleCollection, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		promotions.SortRules(rules)
+		c.JSON(http.StatusOK, rules)
+	}
+}
+func UpdatePromotionRule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ruleID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		rule, ok := bindPromotionRule(c)
+		if !ok {
+			return
+		}
+		rule.ID = ruleID
+		rule, err = database.UpdatePromotionRule(ctx, PromotionRuleCollection, rule)
+		if errors.Is(err, database.ErrRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rule)
+	}
+}
+func DeletePromotionRule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ruleID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		err = database.DeletePromotionRule(ctx, PromotionRuleCollection, ruleID)
+		if errors.Is(err, database.ErrRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Promotion rule deleted"})
+	}
+}
@@ -0,0 +1,171 @@
+package controllers
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	generate "ecommerce/tokens"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var SessionCollection *mongo.Collection = database.UserData(database.Client, "Sessions")
+const maxDeviceNameLength = 100
+func deviceName(c *gin.Context) string {
+	name := c.GetHeader("X-Device-Name")
+	if name == "" {
+		name = c.Request.UserAgent()
+	}
+	if len(name) > maxDeviceNameLength {
+		name = name[:maxDeviceNameLength]
+	}
+	return name
+}
+func createSession(ctx context.Context, c *gin.Context, founduser models.User) (models.Session, string, string, error) {
+	now := time.Now()
+	session := models.Session{
+		ID:           primitive.NewObjectID(),
+		User_ID:      founduser.User_ID,
+		Device_Name:  deviceName(c),
+		User_Agent:   c.Request.UserAgent(),
+		IP:           c.ClientIP(),
+		Created_At:   now,
+		Last_Seen_At: now,
+		Expires_At:   now.Add(generate.DefaultService.RefreshTTL),
+	}
+	token, refreshToken, err := generate.SessionTokenGenerator(*founduser.Email, *founduser.First_Name, *founduser.Last_Name, founduser.User_ID, session.ID.Hex())
+	if err != nil {
+		return session, "", "", err
+	}
+	session.Refresh_Hash = generate.HashOpaqueToken(refreshToken)
+	if _, err = SessionCollection.InsertOne(ctx, session); err != nil {
+		return session, "", "", err
+	}
+	return session, token, refreshToken, nil
+}
+func revokeSessions(ctx context.Context, userID string, except string) error {
+	filter := bson.M{"user_id": userID, "revoked_at": nil}
+	if sid, err := primitive.ObjectIDFromHex(except); err == nil {
+		filter["_id"] = bson.M{"$ne": sid}
+	}
+	_, err := SessionCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	return err
+}
+func RefreshSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.RefreshRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		claims, err := generate.ValidateRefreshToken(request.Refresh_Token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		sid, err := primitive.ObjectIDFromHex(claims.Session_ID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token is not bound to a session"})
+			return
+		}
+		now := time.Now()
+		var session models.Session
+		filter := bson.M{"_id": sid, "user_id": claims.Uid, "revoked_at": nil, "expires_at": bson.M{"$gt": now}}
+		if err := SessionCollection.FindOne(ctx, filter).Decode(&session); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(session.Refresh_Hash), []byte(generate.HashOpaqueToken(request.Refresh_Token))) != 1 {
+			log.Printf("refresh token reuse detected for session %s", session.ID.Hex())
+			if _, err := SessionCollection.UpdateOne(ctx, bson.M{"_id": sid}, bson.M{"$set": bson.M{"revoked_at": now}}); err != nil {
+				log.Println(err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+			return
+		}
+		var founduser models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"user_id": claims.Uid}).Decode(&founduser); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+			return
+		}
+		token, refreshToken, err := generate.SessionTokenGenerator(*founduser.Email, *founduser.First_Name, *founduser.Last_Name, founduser.User_ID, session.ID.Hex())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not issue tokens"})
+			return
+		}
+		update := bson.M{"$set": bson.M{
+			"refresh_hash": generate.HashOpaqueToken(refreshToken),
+			"last_seen_at": now,
+			"expires_at":   now.Add(generate.DefaultService.RefreshTTL),
+			"ip":           c.ClientIP(),
+		}}
+		result, err := SessionCollection.UpdateOne(ctx, bson.M{"_id": sid, "refresh_hash": session.Refresh_Hash}, update)
+		if err != nil || result.ModifiedCount != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+			return
+		}
+		if err := setAuthCookies(c, token); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not issue tokens"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+	}
+}
+func ListSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		sessions := make([]models.Session, 0)
+		filter := bson.M{"user_id": c.GetString("uid"), "revoked_at": nil, "expires_at": bson.M{"$gt": time.Now()}}
+		cursor, err := SessionCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"last_seen_at": -1}))
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list sessions"})
+			return
+		}
+		if err = cursor.All(ctx, &sessions); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list sessions"})
+			return
+		}
+		current := c.GetString("sid")
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID.Hex() == current
+		}
+		c.JSON(http.StatusOK, sessions)
+	}
+}
+func DeleteSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sid, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		filter := bson.M{"_id": sid, "user_id": c.GetString("uid"), "revoked_at": nil}
+		result, err := SessionCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not revoke session"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+	}
+}
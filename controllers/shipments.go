@@ -0,0 +1,171 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"ecommerce/database"
+	"ecommerce/fulfillment"
+	"ecommerce/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var ShipmentCollection *mongo.Collection = database.UserData(database.Client, "Shipments")
+func syncOrderStatus(ctx context.Context, order models.Order, shipments []models.Shipment) (models.Order, error) {
+	status := fulfillment.OrderStatus(order, shipments)
+	if status == order.Status {
+		return order, nil
+	}
+	now := time.Now()
+	order.Status = status
+	if (status == models.OrderShipped || status == models.OrderDelivered) && order.Shipped_At == nil {
+		order.Shipped_At = &now
+	}
+	if status == models.OrderDelivered && order.Delivered_At == nil {
+		order.Delivered_At = &now
+	}
+	return order, database.SetOrderStatus(ctx, UserCollection, order)
+}
+func CreateShipment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.ShipmentCreate
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		user, order, err := database.FindOrder(ctx, UserCollection, orderID)
+		if errors.Is(err, database.ErrOrderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		shipments, err := database.ListShipments(ctx, ShipmentCollection, orderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err = fulfillment.CheckLines(order, shipments, request.Lines); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "remaining": remainingLines(order, shipments)})
+			return
+		}
+		shipment := models.Shipment{
+			Order_ID:        orderID,
+			User_ID:         user.ID.Hex(),
+			Lines:           request.Lines,
+			Carrier:         strings.TrimSpace(request.Carrier),
+			Tracking_Number: strings.TrimSpace(request.Tracking_Number),
+			Status:          request.Status,
+		}
+		if shipment.Status == "" {
+			shipment.Status = models.ShipmentPending
+		}
+		shipment, err = database.CreateShipment(ctx, ShipmentCollection, shipment, shipments)
+		if errors.Is(err, database.ErrShipmentChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err = syncOrderStatus(ctx, order, append(shipments, shipment)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, shipment)
+	}
+}
+func UpdateShipmentStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shipmentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shipment id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.ShipmentStatusUpdate
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		shipment, err := database.FindShipment(ctx, ShipmentCollection, shipmentID)
+		if errors.Is(err, database.ErrShipmentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if !fulfillment.CanTransition(shipment.Status, request.Status) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fulfillment.ErrInvalidTransition.Error(), "status": shipment.Status})
+			return
+		}
+		event := models.ShipmentEvent{Status: request.Status, Note: request.Note, At: time.Now()}
+		shipment, err = database.UpdateShipmentStatus(ctx, ShipmentCollection, shipment, event)
+		if errors.Is(err, database.ErrShipmentChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		_, order, err := database.FindOrder(ctx, UserCollection, shipment.Order_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		shipments, err := database.ListShipments(ctx, ShipmentCollection, shipment.Order_ID)
+		if err == nil {
+			order, err = syncOrderStatus(ctx, order, shipments)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"shipment": shipment, "order_status": order.Status})
+	}
+}
+func remainingLines(order models.Order, shipments []models.Shipment) []models.ShipmentLine {
+	lines := make([]models.ShipmentLine, 0)
+	for key, quantity := range fulfillment.Remaining(order, shipments) {
+		lines = append(lines, models.ShipmentLine{Product_ID: key.Product_ID, Variant_SKU: key.SKU, Quantity: quantity})
+	}
+	return lines
+}
+func ListOrderShipments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		user, order, err := database.FindOrder(ctx, UserCollection, orderID)
+		if err != nil || user.ID.Hex() != c.GetString("uid") {
+			c.JSON(http.StatusNotFound, gin.H{"error": database.ErrOrderNotFound.Error()})
+			return
+		}
+		shipments, err := database.ListShipments(ctx, ShipmentCollection, orderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"order_id": orderID, "status": fulfillment.OrderStatus(order, shipments), "shipments": shipments, "unfulfilled": remainingLines(order, shipments)})
+	}
+}
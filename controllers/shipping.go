@@ -0,0 +1,137 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/promotions"
+	"ecommerce/shipping"
+	"ecommerce/tax"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var ShippingMethodCollection *mongo.Collection = database.UserData(database.Client, "ShippingMethods")
+func shippingAdjuster(code, addressID string) database.OrderAdjuster {
+	return func(ctx context.Context, checkout *database.Checkout) error {
+		order := &checkout.Order
+		methods, err := database.ListShippingMethods(ctx, ShippingMethodCollection, true)
+		if err != nil || len(methods) == 0 {
+			return err
+		}
+		if methods, err = convertShippingMethods(orderConverter(*order), methods); err != nil {
+			return err
+		}
+		total, _, freeShipping, err := promotions.Apply(order.Subtotal, order.Discounts)
+		if err != nil {
+			return err
+		}
+		country := tax.FromAddress(selectedAddress(checkout.User, addressID)).Country
+		quotes, err := shipping.Quote(methods, shipping.NewParcel(order.Order_Cart, checkout.Catalog, country, total, freeShipping))
+		if err != nil {
+			return err
+		}
+		checkout.View.Shipping_Options = quotes
+		quote, err := shipping.Select(quotes, code)
+		if err != nil {
+			if checkout.Preview {
+				return nil
+			}
+			return err
+		}
+		order.Shipping_Method = quote.Code
+		order.Shipping_Cost = quote.Cost
+		return nil
+	}
+}
+func (app *Application) ShippingOptions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		view, err := database.RevalidateCart(ctx, app.prodCollection, app.userCollection, c.GetString("uid"), checkoutAdjusters(c)...)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"currency": view.Currency, "selected": view.Shipping_Method, "options": view.Shipping_Options})
+	}
+}
+func CreateShippingMethod() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.ShippingMethodInput
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		method := models.ShippingMethod{
+			Code:         strings.ToLower(request.Code),
+			Name:         request.Name,
+			Type:         request.Type,
+			Rate:         request.Rate,
+			Weight_Tiers: request.Weight_Tiers,
+			Free_Over:    request.Free_Over,
+			Active:       request.Active == nil || *request.Active,
+		}
+		for _, country := range request.Countries {
+			method.Countries = append(method.Countries, strings.ToUpper(country))
+		}
+		if err := shipping.Validate(method); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		method, err := database.CreateShippingMethod(ctx, ShippingMethodCollection, method)
+		if errors.Is(err, database.ErrShippingCodeTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, method)
+	}
+}
+func ListShippingMethods() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		methods, err := database.ListShippingMethods(ctx, ShippingMethodCollection, c.Query("active") == "true")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, methods)
+	}
+}
+func DeleteShippingMethod() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		methodID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shipping method id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		err = database.DeleteShippingMethod(ctx, ShippingMethodCollection, methodID)
+		if errors.Is(err, database.ErrShippingMethodNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Shipping method deleted"})
+	}
+}
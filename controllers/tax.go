@@ -0,0 +1,136 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/promotions"
+	"ecommerce/tax"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var TaxRuleCollection *mongo.Collection = database.UserData(database.Client, "TaxRules")
+func selectedAddress(user models.User, addressID string) models.Address {
+	for _, address := range user.Address_Details {
+		if address.Address_id.Hex() == addressID {
+			return address
+		}
+	}
+	if len(user.Address_Details) > 0 {
+		return user.Address_Details[0]
+	}
+	return models.Address{}
+}
+func addressAdjuster(addressID string) database.OrderAdjuster {
+	return func(ctx context.Context, checkout *database.Checkout) error {
+		if address := selectedAddress(checkout.User, addressID); !address.Address_id.IsZero() {
+			checkout.Order.Shipping_Address = &address
+		}
+		return nil
+	}
+}
+func taxAdjuster(addressID string) database.OrderAdjuster {
+	return func(ctx context.Context, checkout *database.Checkout) error {
+		order := &checkout.Order
+		jurisdiction := tax.FromAddress(selectedAddress(checkout.User, addressID))
+		if jurisdiction.IsZero() {
+			return nil
+		}
+		rules, err := database.ListTaxRules(ctx, TaxRuleCollection, bson.M{"country": jurisdiction.Country})
+		if err != nil {
+			return err
+		}
+		_, discount, _, err := promotions.Apply(order.Subtotal, order.Discounts)
+		if err != nil {
+			return err
+		}
+		lines := make([]tax.Line, 0, len(order.Order_Cart))
+		for _, line := range order.Order_Cart {
+			lines = append(lines, tax.Line{Product_ID: line.Product_ID, Category: checkout.Catalog[line.Product_ID].Tax_Category, Amount: line.Price})
+		}
+		breakdown, err := tax.Calculate(rules, jurisdiction, lines, discount)
+		if err != nil {
+			return err
+		}
+		order.Tax_Lines = breakdown.Lines
+		order.Tax = breakdown.Total
+		order.Tax_Jurisdiction = jurisdiction.String()
+		return nil
+	}
+}
+func CreateTaxRule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.TaxRuleCreate
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if _, err := tax.ParseRate(request.Rate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		jurisdiction := tax.NewJurisdiction(request.Country, request.Region)
+		rule := models.TaxRule{
+			Name:      request.Name,
+			Country:   jurisdiction.Country,
+			Region:    jurisdiction.Region,
+			Category:  strings.TrimSpace(request.Category),
+			Rate:      request.Rate,
+			Inclusive: request.Inclusive,
+		}
+		rule, err := database.CreateTaxRule(ctx, TaxRuleCollection, rule)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+func ListTaxRules() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		filter := bson.M{}
+		if country := c.Query("country"); country != "" {
+			filter["country"] = strings.ToUpper(country)
+		}
+		rules, err := database.ListTaxRules(ctx, TaxRuleCollection, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rules)
+	}
+}
+func DeleteTaxRule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ruleID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		err = database.DeleteTaxRule(ctx, TaxRuleCollection, ruleID)
+		if errors.Is(err, database.ErrTaxRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Tax rule deleted"})
+	}
+}
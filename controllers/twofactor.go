@@ -0,0 +1,213 @@
+package controllers
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+	"ecommerce/config"
+	"ecommerce/loginguard"
+	"ecommerce/models"
+	generate "ecommerce/tokens"
+	"ecommerce/totp"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+const recoveryCodeCount = 10
+func verifyTOTP(ctx context.Context, founduser models.User, code string) bool {
+	if founduser.Totp_Secret == nil {
+		return false
+	}
+	step, ok := totp.Validate(*founduser.Totp_Secret, code, time.Now(), 1)
+	if !ok {
+		return false
+	}
+	filter := bson.M{"user_id": founduser.User_ID, "totp_last_step": bson.M{"$lt": step}}
+	result, err := UserCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totp_last_step": step}})
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return result.ModifiedCount == 1
+}
+func useRecoveryCode(ctx context.Context, founduser models.User, code string) bool {
+	hashed := generate.HashOpaqueToken(totp.NormalizeRecoveryCode(code))
+	filter := bson.M{"user_id": founduser.User_ID, "recovery_codes": hashed}
+	result, err := UserCollection.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"recovery_codes": hashed}})
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return result.ModifiedCount == 1
+}
+func SetupTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		uid := c.GetString("uid")
+		var founduser models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"user_id": uid}).Decode(&founduser); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if founduser.Totp_Enabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+			return
+		}
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate secret"})
+			return
+		}
+		_, err = UserCollection.UpdateOne(ctx, bson.M{"user_id": uid}, bson.M{"$set": bson.M{"totp_pending": secret}})
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not start two-factor setup"})
+			return
+		}
+		issuer := config.String("TOTP_ISSUER", "Ecommerce")
+		c.JSON(http.StatusOK, models.TwoFactorSetup{Secret: secret, OtpAuth_URI: totp.URI(issuer, *founduser.Email, secret)})
+	}
+}
+func ConfirmTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		uid := c.GetString("uid")
+		var request models.TwoFactorCode
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		var founduser models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"user_id": uid}).Decode(&founduser); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if founduser.Totp_Pending == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor setup has not been started"})
+			return
+		}
+		step, ok := totp.Validate(*founduser.Totp_Pending, request.Code, time.Now(), 1)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+			return
+		}
+		codes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate recovery codes"})
+			return
+		}
+		hashed := make([]string, 0, len(codes))
+		for _, code := range codes {
+			hashed = append(hashed, generate.HashOpaqueToken(code))
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"totp_enabled":   true,
+				"totp_secret":    *founduser.Totp_Pending,
+				"totp_last_step": step,
+				"recovery_codes": hashed,
+				"updated_at":     time.Now(),
+			},
+			"$unset": bson.M{"totp_pending": ""},
+		}
+		if _, err = UserCollection.UpdateOne(ctx, bson.M{"user_id": uid}, update); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not enable two-factor authentication"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+func DisableTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		uid := c.GetString("uid")
+		var request models.TwoFactorDisable
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		var founduser models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"user_id": uid}).Decode(&founduser); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if !founduser.Totp_Enabled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is not enabled"})
+			return
+		}
+		PasswordIsValid, msg := verifyUserPassword(founduser, request.Password)
+		if !PasswordIsValid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
+		}
+		if !verifyTOTP(ctx, founduser, request.Code) && !useRecoveryCode(ctx, founduser, request.Code) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+			return
+		}
+		update := bson.M{
+			"$set":   bson.M{"totp_enabled": false, "totp_last_step": 0, "updated_at": time.Now()},
+			"$unset": bson.M{"totp_secret": "", "totp_pending": "", "recovery_codes": ""},
+		}
+		if _, err := UserCollection.UpdateOne(ctx, bson.M{"user_id": uid}, update); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not disable two-factor authentication"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+	}
+}
+func LoginTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.TwoFactorLogin
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		claims, err := generate.ValidateChallenge(request.Challenge_Token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var founduser models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"user_id": claims.Uid, "anonymized_at": nil}).Decode(&founduser); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "login or password incorrect"})
+			return
+		}
+		userKey := loginguard.UserKey(founduser.User_ID)
+		ipKey := loginguard.IPKey(c.ClientIP())
+		if !loginAllowed(ctx, c, userKey, ipKey) {
+			return
+		}
+		var ok bool
+		if request.Recovery_Code != "" {
+			ok = useRecoveryCode(ctx, founduser, request.Recovery_Code)
+		} else {
+			ok = verifyTOTP(ctx, founduser, request.Code)
+		}
+		if !ok {
+			recordLoginFailure(ctx, c, founduser.User_ID, userKey, ipKey)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+			return
+		}
+		completeLogin(c, founduser)
+	}
+}
@@ -0,0 +1,64 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	"ecommerce/variants"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func cartSelection(c *gin.Context) variants.Selection {
+	return variants.Selection{SKU: c.Query("sku"), Options: c.QueryMap("options")}
+}
+func isVariantError(err error) bool {
+	for _, target := range []error{
+		variants.ErrVariantRequired,
+		variants.ErrVariantNotFound,
+		variants.ErrNoVariants,
+		variants.ErrInvalidOptions,
+		variants.ErrInvalidVariant,
+		variants.ErrMissingPrice,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+func SetProductVariants() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.ProductVariants
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		product, err := database.SetProductVariants(ctx, ProductCollection, productID, request)
+		if errors.Is(err, database.ErrCantFindProduct) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, database.ErrSKUTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if isVariantError(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, product)
+	}
+}
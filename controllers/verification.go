@@ -0,0 +1,120 @@
+package controllers
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"ecommerce/config"
+	"ecommerce/mailer"
+	"ecommerce/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+const emailVerificationTTL = 48 * time.Hour
+func sendVerificationEmail(ctx context.Context, user models.User) error {
+	rawtoken, err := issueUserToken(ctx, user.User_ID, models.PurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return Mail.Send(mailer.Message{
+		To:      *user.Email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Confirm your email address by opening the link below. It expires in %s.\n\n%s/users/verify?token=%s", emailVerificationTTL, appURL(), rawtoken),
+	})
+}
+func userIsVerified(ctx context.Context, userID string, grandfatheredBefore time.Time) (bool, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+	var founduser models.User
+	opts := options.FindOne().SetProjection(bson.M{"verified_at": 1, "created_at": 1})
+	if err := UserCollection.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&founduser); err != nil {
+		return false, err
+	}
+	return founduser.Verified_At != nil || founduser.Created_At.Before(grandfatheredBefore), nil
+}
+func requireVerifiedEmail(ctx context.Context, c *gin.Context, userID string) bool {
+	if !config.Bool("REQUIRE_VERIFIED_EMAIL", false) {
+		return true
+	}
+	verified, err := userIsVerified(ctx, userID, config.Time("REQUIRE_VERIFIED_EMAIL_AFTER", time.Time{}))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not check email verification"})
+		return false
+	}
+	if !verified {
+		c.JSON(http.StatusForbidden, gin.H{"error": "email address must be verified before checkout"})
+		return false
+	}
+	return true
+}
+func VerifyEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawtoken := c.Query("token")
+		if rawtoken == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		usertoken, err := consumeUserToken(ctx, rawtoken, models.PurposeEmailVerification)
+		if err != nil {
+			if err != mongo.ErrNoDocuments {
+				log.Println(err)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "verification token is invalid or expired"})
+			return
+		}
+		filter := bson.M{"user_id": usertoken.User_ID, "verified_at": nil}
+		_, err = UserCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"verified_at": time.Now()}})
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not verify email"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+	}
+}
+func ResendVerification() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		uid := c.GetString("uid")
+		var founduser models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"user_id": uid}).Decode(&founduser); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if founduser.Verified_At != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "email is already verified"})
+			return
+		}
+		interval := config.Duration("VERIFY_RESEND_INTERVAL", time.Minute)
+		var last models.UserToken
+		opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+		err := UserTokenCollection.FindOne(ctx, bson.M{"user_id": uid, "purpose": models.PurposeEmailVerification}, opts).Decode(&last)
+		if err == nil {
+			if wait := time.Until(last.Created_At.Add(interval)); wait > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "verification email was sent recently, try again later"})
+				return
+			}
+		} else if err != mongo.ErrNoDocuments {
+			log.Println(err)
+		}
+		if err := sendVerificationEmail(ctx, founduser); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not send verification email"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+	}
+}
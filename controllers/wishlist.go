@@ -0,0 +1,110 @@
+package controllers
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"ecommerce/database"
+	"ecommerce/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func wishlistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, database.ErrCantFindProduct), errors.Is(err, database.ErrNotInWishlist), errors.Is(err, database.ErrNotInCart):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrAlreadyInWishlist):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case isVariantError(err):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+func (app *Application) GetWishlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		wishlist, err := database.GetWishlist(ctx, app.prodCollection, app.userCollection, c.GetString("uid"))
+		if err != nil {
+			wishlistError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, wishlist)
+	}
+}
+func (app *Application) AddToWishlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		var request models.WishlistAdd
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := Validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		productID, _ := primitive.ObjectIDFromHex(request.Product_ID)
+		err := database.AddProductToWishlist(ctx, app.prodCollection, app.userCollection, productID, c.GetString("uid"))
+		if err != nil {
+			wishlistError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Added to wishlist"})
+	}
+}
+func (app *Application) RemoveFromWishlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		err = database.RemoveWishlistItem(ctx, app.userCollection, productID, c.GetString("uid"))
+		if err != nil {
+			wishlistError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Removed from wishlist"})
+	}
+}
+func (app *Application) SaveForLater() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		err = database.SaveCartItemForLater(ctx, app.prodCollection, app.userCollection, productID, c.GetString("uid"), cartSelection(c))
+		if err != nil {
+			wishlistError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Saved for later"})
+	}
+}
+func (app *Application) MoveToCart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+			return
+		}
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		err = database.MoveWishlistItemToCart(ctx, app.prodCollection, app.userCollection, productID, c.GetString("uid"), cartSelection(c))
+		if err != nil {
+			wishlistError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Moved to cart"})
+	}
+}
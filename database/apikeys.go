@@ -0,0 +1,28 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var (
+	ErrCantIndexAPIKeys  = errors.New("cannot create api key indexes")
+	ErrCantRevokeAPIKeys = errors.New("cannot revoke api keys")
+)
+func EnsureAPIKeyIndexes(ctx context.Context, apikeyCollection *mongo.Collection) error {
+	index := mongo.IndexModel{Keys: bson.D{{Key: "prefix", Value: 1}}, Options: options.Index().SetUnique(true)}
+	if _, err := apikeyCollection.Indexes().CreateOne(ctx, index); err != nil {
+		log.Println(err)
+		return ErrCantIndexAPIKeys
+	}
+	return nil
+}
+func RevokeAPIKeys(ctx context.Context, apikeyCollection *mongo.Collection, userID string) error {
+	if _, err := apikeyCollection.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		log.Println(err)
+		return ErrCantRevokeAPIKeys
+	}
+	return nil
+}
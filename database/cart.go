@@ -4,10 +4,16 @@ import (
 	"errors"
 	"log"
 	"time"
+	"ecommerce/cart"
 	"ecommerce/models"
+	"ecommerce/money"
+	"ecommerce/promotions"
+	"ecommerce/tax"
+	"ecommerce/variants"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 var (
 	ErrCantFindProduct    = errors.New("can't find product")
@@ -17,18 +23,14 @@ var (
 	ErrCantRemoveItem     = errors.New("cannot remove item from cart")
 	ErrCantGetItem        = errors.New("cannot get item from cart ")
 	ErrCantBuyCartItem    = errors.New("cannot update the purchase")
+	ErrCartChanged        = errors.New("cart has changed since it was last reviewed")
+	ErrCartEmpty          = errors.New("cart is empty")
+	ErrOutOfStock         = errors.New("not enough stock to complete the purchase")
 )
-func AddProductToCart(ctx context.Context, prodCollection, userCollection *mongo.Collection, productID primitive.ObjectID, userID string) error {
-	searchfromdb, err := prodCollection.Find(ctx, bson.M{"_id": productID})
+func AddProductToCart(ctx context.Context, prodCollection, userCollection *mongo.Collection, productID primitive.ObjectID, userID string, selection variants.Selection) error {
+	line, err := findCartLine(ctx, prodCollection, productID, selection)
 	if err != nil {
-		log.Println(err)
-		return ErrCantFindProduct
-	}
-	var productcart []models.ProductUser
-	err = searchfromdb.All(ctx, &productcart)
-	if err != nil {
-		log.Println(err)
-		return ErrCantDecodeProducts
+		return err
 	}
 	id, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
@@ -36,110 +38,297 @@ func AddProductToCart(ctx context.Context, prodCollection, userCollection *mongo
 		return ErrUserIDIsNotValid
 	}
 	filter := bson.D{primitive.E{Key: "_id", Value: id}}
-	update := bson.D{{Key: "$push", Value: bson.D{primitive.E{Key: "usercart", Value: bson.D{{Key: "$each", Value: productcart}}}}}}
+	update := bson.D{{Key: "$push", Value: bson.D{primitive.E{Key: "usercart", Value: line}}}}
 	_, err = userCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return ErrCantUpdateUser
 	}
 	return nil
 }
-func RemoveCartItem(ctx context.Context, prodCollection, userCollection *mongo.Collection, productID primitive.ObjectID, userID string) error {
+func cartLineFilter(ctx context.Context, prodCollection *mongo.Collection, productID primitive.ObjectID, sku string) (bson.M, error) {
+	if sku != "" {
+		return bson.M{"_id": productID, "variant_sku": sku}, nil
+	}
+	var product models.Product
+	err := prodCollection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Println(err)
+		return nil, ErrCantFindProduct
+	}
+	if len(product.Variants) > 0 {
+		return nil, variants.ErrVariantRequired
+	}
+	return bson.M{"_id": productID}, nil
+}
+func RemoveCartItem(ctx context.Context, prodCollection, userCollection *mongo.Collection, productID primitive.ObjectID, userID string, sku string) error {
 	id, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		log.Println(err)
 		return ErrUserIDIsNotValid
 	}
+	line, err := cartLineFilter(ctx, prodCollection, productID, sku)
+	if err != nil {
+		return err
+	}
 	filter := bson.D{primitive.E{Key: "_id", Value: id}}
-	update := bson.M{"$pull": bson.M{"usercart": bson.M{"_id": productID}}}
+	update := bson.M{"$pull": bson.M{"usercart": line}}
 	_, err = userCollection.UpdateMany(ctx, filter, update)
 	if err != nil {
 		return ErrCantRemoveItem
 	}
 	return nil
 }
-func BuyItemFromCart(ctx context.Context, userCollection *mongo.Collection, userID string) error {
-	id, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		log.Println(err)
-		return ErrUserIDIsNotValid
+func LoadCatalog(ctx context.Context, prodCollection *mongo.Collection, lines []models.ProductUser) (map[primitive.ObjectID]models.Product, error) {
+	catalog := make(map[primitive.ObjectID]models.Product)
+	if len(lines) == 0 {
+		return catalog, nil
 	}
-	var getcartitems models.User
-	var ordercart models.Order
-	ordercart.Order_ID = primitive.NewObjectID()
-	ordercart.Orderered_At = time.Now()
-	ordercart.Order_Cart = make([]models.ProductUser, 0)
-	ordercart.Payment_Method.COD = true
-	unwind := bson.D{{Key: "$unwind", Value: bson.D{primitive.E{Key: "path", Value: "$usercart"}}}}
-	grouping := bson.D{{Key: "$group", Value: bson.D{primitive.E{Key: "_id", Value: "$_id"}, {Key: "total", Value: bson.D{primitive.E{Key: "$sum", Value: "$usercart.price"}}}}}}
-	currentresults, err := userCollection.Aggregate(ctx, mongo.Pipeline{unwind, grouping})
-	ctx.Done()
-	if err != nil {
-		panic(err)
-	}
-	var getusercart []bson.M
-	if err = currentresults.All(ctx, &getusercart); err != nil {
-		panic(err)
-	}
-	var total_price int32
-	for _, user_item := range getusercart {
-		price := user_item["total"]
-		total_price = price.(int32)
-	}
-	ordercart.Price = int(total_price)
-	filter := bson.D{primitive.E{Key: "_id", Value: id}}
-	update := bson.D{{Key: "$push", Value: bson.D{primitive.E{Key: "orders", Value: ordercart}}}}
-	_, err = userCollection.UpdateMany(ctx, filter, update)
+	cursor, err := prodCollection.Find(ctx, bson.M{"_id": bson.M{"$in": cart.ProductIDs(lines)}})
 	if err != nil {
 		log.Println(err)
+		return nil, ErrCantFindProduct
 	}
-	err = userCollection.FindOne(ctx, bson.D{primitive.E{Key: "_id", Value: id}}).Decode(&getcartitems)
-	if err != nil {
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
 		log.Println(err)
+		return nil, ErrCantDecodeProducts
+	}
+	for _, product := range products {
+		catalog[product.Product_ID] = product
 	}
-	filter2 := bson.D{primitive.E{Key: "_id", Value: id}}
-	update2 := bson.M{"$push": bson.M{"orders.$[].order_list": bson.M{"$each": getcartitems.UserCart}}}
-	_, err = userCollection.UpdateOne(ctx, filter2, update2)
+	return catalog, nil
+}
+type Checkout struct {
+	User    models.User
+	Catalog map[primitive.ObjectID]models.Product
+	View    models.CartView
+	Order   models.Order
+	Preview bool
+	commits []checkoutCommit
+	cart    bson.RawValue
+}
+type checkoutCommit struct {
+	commit   func(ctx context.Context) error
+	rollback func(ctx context.Context) error
+}
+type OrderAdjuster func(ctx context.Context, checkout *Checkout) error
+func (checkout *Checkout) OnCommit(commit func(ctx context.Context) error, rollback func(ctx context.Context) error) {
+	checkout.commits = append(checkout.commits, checkoutCommit{commit: commit, rollback: rollback})
+}
+func (checkout *Checkout) rollback(ctx context.Context, completed int) {
+	for i := completed - 1; i >= 0; i-- {
+		if checkout.commits[i].rollback == nil {
+			continue
+		}
+		if err := checkout.commits[i].rollback(ctx); err != nil {
+			log.Println(err)
+		}
+	}
+}
+func (checkout *Checkout) adjust(ctx context.Context, adjusters []OrderAdjuster) error {
+	checkout.View.Applied_Coupon = checkout.User.Applied_Coupon
+	for _, adjuster := range adjusters {
+		if err := adjuster(ctx, checkout); err != nil {
+			return err
+		}
+	}
+	order := &checkout.Order
+	total, discount, freeShipping, err := promotions.Apply(order.Subtotal, order.Discounts)
 	if err != nil {
-		log.Println(err)
+		return err
 	}
-	usercart_empty := make([]models.ProductUser, 0)
-	filtered := bson.D{primitive.E{Key: "_id", Value: id}}
-	updated := bson.D{{Key: "$set", Value: bson.D{primitive.E{Key: "usercart", Value: usercart_empty}}}}
-	_, err = userCollection.UpdateOne(ctx, filtered, updated)
+	exclusiveTax, err := tax.Exclusive(order.Tax_Lines, total.Currency)
 	if err != nil {
-		return ErrCantBuyCartItem
-
+		return err
 	}
+	if order.Price, err = money.Sum(total.Currency, total, exclusiveTax, order.Shipping_Cost); err != nil {
+		return err
+	}
+	order.Free_Shipping = freeShipping
+	if len(order.Discounts) > 0 {
+		order.Discount = &discount
+	}
+	checkout.View.UserCart = order.Order_Cart
+	checkout.View.Total = order.Subtotal
+	checkout.View.Discounts = order.Discounts
+	checkout.View.Discount = discount
+	checkout.View.Free_Shipping = freeShipping
+	checkout.View.Grand_Total = order.Price
+	checkout.View.Currency = order.Currency
+	checkout.View.Exchange_Rate = order.Exchange_Rate
+	checkout.View.Tax = order.Tax
+	checkout.View.Tax_Lines = order.Tax_Lines
+	checkout.View.Shipping_Method = order.Shipping_Method
+	checkout.View.Shipping_Cost = order.Shipping_Cost
 	return nil
 }
-func InstantBuyer(ctx context.Context, prodCollection, userCollection *mongo.Collection, productID primitive.ObjectID, UserID string) error {
-	id, err := primitive.ObjectIDFromHex(UserID)
+func findCheckoutUser(ctx context.Context, userCollection *mongo.Collection, userID string) (models.User, bson.RawValue, error) {
+	var user models.User
+	id, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		log.Println(err)
-		return ErrUserIDIsNotValid
+		return user, bson.RawValue{}, ErrUserIDIsNotValid
+	}
+	raw, err := userCollection.FindOne(ctx, bson.M{"_id": id}).Raw()
+	if err == nil {
+		err = bson.Unmarshal(raw, &user)
 	}
-	var product_details models.ProductUser
-	var orders_detail models.Order
-	orders_detail.Order_ID = primitive.NewObjectID()
-	orders_detail.Orderered_At = time.Now()
-	orders_detail.Order_Cart = make([]models.ProductUser, 0)
-	orders_detail.Payment_Method.COD = true
-	err = prodCollection.FindOne(ctx, bson.D{primitive.E{Key: "_id", Value: productID}}).Decode(&product_details)
 	if err != nil {
 		log.Println(err)
+		return user, bson.RawValue{}, ErrCantGetItem
 	}
-	orders_detail.Price = product_details.Price
-	filter := bson.D{primitive.E{Key: "_id", Value: id}}
-	update := bson.D{{Key: "$push", Value: bson.D{primitive.E{Key: "orders", Value: orders_detail}}}}
-	_, err = userCollection.UpdateOne(ctx, filter, update)
+	return user, raw.Lookup("usercart"), nil
+}
+func LoadCheckout(ctx context.Context, prodCollection, userCollection *mongo.Collection, userID string) (*Checkout, error) {
+	user, usercart, err := findCheckoutUser(ctx, userCollection, userID)
 	if err != nil {
-		log.Println(err)
+		return nil, err
+	}
+	checkout := &Checkout{User: user, cart: usercart}
+	checkout.Catalog, err = LoadCatalog(ctx, prodCollection, checkout.User.UserCart)
+	if err != nil {
+		return nil, err
+	}
+	if checkout.View, err = cart.Revalidate(checkout.User.UserCart, checkout.Catalog); err != nil {
+		return nil, err
+	}
+	checkout.Order = newOrder(checkout.View.UserCart, checkout.View.Total)
+	return checkout, nil
+}
+func RevalidateCart(ctx context.Context, prodCollection, userCollection *mongo.Collection, userID string, adjusters ...OrderAdjuster) (models.CartView, error) {
+	checkout, err := LoadCheckout(ctx, prodCollection, userCollection, userID)
+	if err != nil {
+		return models.CartView{}, err
+	}
+	checkout.Preview = true
+	if err = checkout.adjust(ctx, adjusters); err != nil {
+		return checkout.View, err
+	}
+	return checkout.View, nil
+}
+func stockFilter(key cart.LineKey, condition interface{}) bson.M {
+	if key.SKU == "" {
+		return bson.M{"_id": key.Product_ID, "stock": condition}
+	}
+	return bson.M{"_id": key.Product_ID, "variants": bson.M{"$elemMatch": bson.M{"sku": key.SKU, "stock": condition}}}
+}
+func stockIncrement(key cart.LineKey, quantity int) (bson.M, *options.UpdateOptions) {
+	if key.SKU == "" {
+		return bson.M{"$inc": bson.M{"stock": quantity}}, options.Update()
+	}
+	arrayFilters := options.ArrayFilters{Filters: []interface{}{bson.M{"v.sku": key.SKU}}}
+	return bson.M{"$inc": bson.M{"variants.$[v].stock": quantity}}, options.Update().SetArrayFilters(arrayFilters)
+}
+func reserveStock(ctx context.Context, prodCollection *mongo.Collection, lines []models.ProductUser) (map[cart.LineKey]int, error) {
+	reserved := make(map[cart.LineKey]int)
+	for key, quantity := range cart.Quantities(lines) {
+		update, opts := stockIncrement(key, -quantity)
+		result, err := prodCollection.UpdateOne(ctx, stockFilter(key, bson.M{"$gte": quantity}), update, opts)
+		if err == nil && result.MatchedCount == 1 {
+			reserved[key] = quantity
+			continue
+		}
+		if err == nil {
+			var untracked int64
+			untracked, err = prodCollection.CountDocuments(ctx, stockFilter(key, nil))
+			if err == nil && untracked == 1 {
+				continue
+			}
+		}
+		if err != nil {
+			log.Println(err)
+		}
+		releaseStock(ctx, prodCollection, reserved)
+		return nil, ErrOutOfStock
+	}
+	return reserved, nil
+}
+func releaseStock(ctx context.Context, prodCollection *mongo.Collection, reserved map[cart.LineKey]int) {
+	for key, quantity := range reserved {
+		update, opts := stockIncrement(key, quantity)
+		if _, err := prodCollection.UpdateOne(ctx, bson.M{"_id": key.Product_ID}, update, opts); err != nil {
+			log.Println(err)
+		}
+	}
+}
+func newOrder(lines []models.ProductUser, total money.Money) models.Order {
+	var order models.Order
+	order.Order_ID = primitive.NewObjectID()
+	order.Orderered_At = time.Now()
+	order.Order_Cart = lines
+	order.Subtotal = total
+	order.Price = total
+	order.Currency = total.Currency
+	order.Exchange_Rate = "1"
+	order.Payment_Method.COD = true
+	order.Status = models.OrderUnfulfilled
+	return order
+}
+func placeOrder(ctx context.Context, prodCollection, userCollection *mongo.Collection, checkout *Checkout, filter bson.M, update bson.M) error {
+	reserved, err := reserveStock(ctx, prodCollection, checkout.Order.Order_Cart)
+	if err != nil {
+		return err
+	}
+	for i, commit := range checkout.commits {
+		if err = commit.commit(ctx); err != nil {
+			checkout.rollback(ctx, i)
+			releaseStock(ctx, prodCollection, reserved)
+			return err
+		}
+	}
+	result, err := userCollection.UpdateOne(ctx, filter, update)
+	if err != nil || result.MatchedCount == 0 {
+		checkout.rollback(ctx, len(checkout.commits))
+		releaseStock(ctx, prodCollection, reserved)
 	}
-	filter2 := bson.D{primitive.E{Key: "_id", Value: id}}
-	update2 := bson.M{"$push": bson.M{"orders.$[].order_list": product_details}}
-	_, err = userCollection.UpdateOne(ctx, filter2, update2)
 	if err != nil {
 		log.Println(err)
+		return ErrCantBuyCartItem
+	}
+	if result.MatchedCount == 0 {
+		return ErrCartChanged
 	}
 	return nil
+}
+func BuyItemFromCart(ctx context.Context, prodCollection, userCollection *mongo.Collection, userID string, acknowledged string, adjusters ...OrderAdjuster) (models.CartView, error) {
+	checkout, err := LoadCheckout(ctx, prodCollection, userCollection, userID)
+	if err != nil {
+		return models.CartView{}, err
+	}
+	view := checkout.View
+	if view.Fingerprint != "" && view.Fingerprint != acknowledged {
+		return view, ErrCartChanged
+	}
+	if len(view.UserCart) == 0 {
+		return view, ErrCartEmpty
+	}
+	if err = checkout.adjust(ctx, adjusters); err != nil {
+		return checkout.View, err
+	}
+	update := bson.M{
+		"$push":  bson.M{"orders": checkout.Order},
+		"$set":   bson.M{"usercart": make([]models.ProductUser, 0)},
+		"$unset": bson.M{"applied_coupon": ""},
+	}
+	filter := bson.M{"_id": checkout.User.ID, "usercart": checkout.cart}
+	return checkout.View, placeOrder(ctx, prodCollection, userCollection, checkout, filter, update)
+}
+func InstantBuyer(ctx context.Context, prodCollection, userCollection *mongo.Collection, productID primitive.ObjectID, UserID string, selection variants.Selection, adjusters ...OrderAdjuster) error {
+	user, _, err := findCheckoutUser(ctx, userCollection, UserID)
+	if err != nil {
+		return err
+	}
+	product_details, err := findCartLine(ctx, prodCollection, productID, selection)
+	if err != nil {
+		return err
+	}
+	lines := []models.ProductUser{product_details}
+	checkout := &Checkout{User: user, View: models.CartView{UserCart: lines, Total: product_details.Price}, Order: newOrder(lines, product_details.Price)}
+	if checkout.Catalog, err = LoadCatalog(ctx, prodCollection, lines); err != nil {
+		return err
+	}
+	if err = checkout.adjust(ctx, adjusters); err != nil {
+		return err
+	}
+	return placeOrder(ctx, prodCollection, userCollection, checkout, bson.M{"_id": checkout.User.ID}, bson.M{"$push": bson.M{"orders": checkout.Order}})
 }
\ No newline at end of file
@@ -11,6 +11,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"ecommerce/models"
+	"ecommerce/money"
+	"ecommerce/variants"
 )
 var (
 	client         *mongo.Client
@@ -45,7 +47,7 @@ func teardown() {
 func setupProductAndUser(t *testing.T, productID primitive.ObjectID, userID primitive.ObjectID) {
 	product := models.ProductUser{
 		Product_ID: productID,
-		Price:      100,
+		Price:      money.New(100, ""),
 	}
 	_, err := mockProdColl.InsertOne(context.Background(), product)
 	require.NoError(t, err)
@@ -62,7 +64,7 @@ func TestAddProductToCart(t *testing.T) {
 	productID := primitive.NewObjectID()
 	userID := primitive.NewObjectID()
 	setupProductAndUser(t, productID, userID)
-	err := AddProductToCart(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex())
+	err := AddProductToCart(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), variants.Selection{})
 	require.NoError(t, err)
 	var updatedUser models.User
 	err = mockUserColl.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&updatedUser)
@@ -75,7 +77,7 @@ func TestRemoveCartItem(t *testing.T) {
 	productID := primitive.NewObjectID()
 	userID := primitive.NewObjectID()
 	setupProductAndUser(t, productID, userID)
-	err := RemoveCartItem(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex())
+	err := RemoveCartItem(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), "")
 	require.NoError(t, err)
 	var updatedUser models.User
 	err = mockUserColl.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&updatedUser)
@@ -88,12 +90,39 @@ func TestBuyItemFromCart(t *testing.T) {
 	productID := primitive.NewObjectID()
 	userID := primitive.NewObjectID()
 	setupProductAndUser(t, productID, userID)
-	err := BuyItemFromCart(context.Background(), mockUserColl, userID.Hex())
+	_, err := BuyItemFromCart(context.Background(), mockProdColl, mockUserColl, userID.Hex(), "")
 	require.NoError(t, err)
 	var updatedUser models.User
 	err = mockUserColl.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&updatedUser)
 	require.NoError(t, err)
 	assert.Empty(t, updatedUser.UserCart)
+	require.Len(t, updatedUser.Order_Status, 1)
+	assert.Equal(t, money.New(100, ""), updatedUser.Order_Status[0].Price)
+	assert.Len(t, updatedUser.Order_Status[0].Order_Cart, 1)
+}
+func TestBuyItemFromCartRequiresAcknowledgedChanges(t *testing.T) {
+	setup()
+	defer teardown()
+	productID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	setupProductAndUser(t, productID, userID)
+	_, err := mockProdColl.UpdateOne(context.Background(), bson.M{"_id": productID}, bson.M{"$set": bson.M{"price": money.New(120, ""), "stock": 5}})
+	require.NoError(t, err)
+	view, err := BuyItemFromCart(context.Background(), mockProdColl, mockUserColl, userID.Hex(), "")
+	require.ErrorIs(t, err, ErrCartChanged)
+	require.Len(t, view.Warnings, 1)
+	assert.Equal(t, models.CartWarningPriceChanged, view.Warnings[0].Code)
+	_, err = BuyItemFromCart(context.Background(), mockProdColl, mockUserColl, userID.Hex(), view.Fingerprint)
+	require.NoError(t, err)
+	var updatedUser models.User
+	err = mockUserColl.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&updatedUser)
+	require.NoError(t, err)
+	require.Len(t, updatedUser.Order_Status, 1)
+	assert.Equal(t, money.New(120, ""), updatedUser.Order_Status[0].Price)
+	var product models.Product
+	err = mockProdColl.FindOne(context.Background(), bson.M{"_id": productID}).Decode(&product)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), *product.Stock)
 }
 func TestInstantBuyer(t *testing.T) {
 	setup()
@@ -101,10 +130,100 @@ func TestInstantBuyer(t *testing.T) {
 	productID := primitive.NewObjectID()
 	userID := primitive.NewObjectID()
 	setupProductAndUser(t, productID, userID)
-	err := InstantBuyer(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex())
+	err := InstantBuyer(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), variants.Selection{})
+	require.NoError(t, err)
+	var updatedUser models.User
+	err = mockUserColl.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&updatedUser)
+	require.NoError(t, err)
+	require.Len(t, updatedUser.Order_Status, 1)
+	assert.Len(t, updatedUser.Order_Status[0].Order_Cart, 1)
+}
+func TestWishlistSaveForLaterAndBack(t *testing.T) {
+	setup()
+	defer teardown()
+	productID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	setupProductAndUser(t, productID, userID)
+	err := SaveCartItemForLater(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), variants.Selection{})
+	require.NoError(t, err)
+	var updatedUser models.User
+	err = mockUserColl.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&updatedUser)
+	require.NoError(t, err)
+	assert.Empty(t, updatedUser.UserCart)
+	require.Len(t, updatedUser.Wishlist, 1)
+	assert.Equal(t, money.New(100, ""), updatedUser.Wishlist[0].Price_At_Add)
+	_, err = mockProdColl.UpdateOne(context.Background(), bson.M{"_id": productID}, bson.M{"$set": bson.M{"price": money.New(80, "")}})
+	require.NoError(t, err)
+	wishlist, err := GetWishlist(context.Background(), mockProdColl, mockUserColl, userID.Hex())
+	require.NoError(t, err)
+	require.Len(t, wishlist, 1)
+	assert.True(t, wishlist[0].Price_Dropped)
+	err = MoveWishlistItemToCart(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), variants.Selection{})
+	require.NoError(t, err)
+	err = mockUserColl.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&updatedUser)
+	require.NoError(t, err)
+	assert.Len(t, updatedUser.UserCart, 1)
+	assert.Empty(t, updatedUser.Wishlist)
+	assert.ErrorIs(t, RemoveWishlistItem(context.Background(), mockUserColl, productID, userID.Hex()), ErrNotInWishlist)
+}
+func TestSaveForLaterMovesOneLineAtCurrentPrice(t *testing.T) {
+	setup()
+	defer teardown()
+	productID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	setupProductAndUser(t, productID, userID)
+	require.NoError(t, AddProductToCart(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), variants.Selection{}))
+	_, err := mockProdColl.UpdateOne(context.Background(), bson.M{"_id": productID}, bson.M{"$set": bson.M{"price": money.New(90, "")}})
+	require.NoError(t, err)
+	err = SaveCartItemForLater(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), variants.Selection{})
+	require.NoError(t, err)
+	var updatedUser models.User
+	err = mockUserColl.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&updatedUser)
+	require.NoError(t, err)
+	assert.Len(t, updatedUser.UserCart, 1)
+	require.Len(t, updatedUser.Wishlist, 1)
+	assert.Equal(t, money.New(90, ""), updatedUser.Wishlist[0].Price_At_Add)
+}
+func TestAddVariantToCartAndBuy(t *testing.T) {
+	setup()
+	defer teardown()
+	productID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	price := money.New(100, "")
+	large := money.New(120, "")
+	stock := int64(2)
+	product := models.Product{
+		Product_ID: productID,
+		Price:      &price,
+		Options:    []models.ProductOption{{Name: "size", Values: []string{"M", "L"}}},
+		Variants: []models.Variant{
+			{SKU: "TEE-M", Options: map[string]string{"size": "M"}, Stock: &stock},
+			{SKU: "TEE-L", Options: map[string]string{"size": "L"}, Price: &large, Stock: &stock},
+		},
+	}
+	_, err := mockProdColl.InsertOne(context.Background(), product)
+	require.NoError(t, err)
+	_, err = mockUserColl.InsertOne(context.Background(), models.User{ID: userID, UserCart: []models.ProductUser{}})
+	require.NoError(t, err)
+	err = AddProductToCart(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), variants.Selection{})
+	require.ErrorIs(t, err, variants.ErrVariantRequired)
+	err = AddProductToCart(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), variants.Selection{Options: map[string]string{"size": "XL"}})
+	require.ErrorIs(t, err, variants.ErrVariantNotFound)
+	err = AddProductToCart(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), variants.Selection{Options: map[string]string{"size": "L"}})
+	require.NoError(t, err)
+	err = RemoveCartItem(context.Background(), mockProdColl, mockUserColl, productID, userID.Hex(), "")
+	require.ErrorIs(t, err, variants.ErrVariantRequired)
+	_, err = BuyItemFromCart(context.Background(), mockProdColl, mockUserColl, userID.Hex(), "")
 	require.NoError(t, err)
 	var updatedUser models.User
 	err = mockUserColl.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&updatedUser)
 	require.NoError(t, err)
-	assert.Len(t, updatedUser.Order_Status, 0)
-}
\ No newline at end of file
+	require.Len(t, updatedUser.Order_Status, 1)
+	assert.Equal(t, large, updatedUser.Order_Status[0].Price)
+	assert.Equal(t, "TEE-L", updatedUser.Order_Status[0].Order_Cart[0].Variant_SKU)
+	var updated models.Product
+	err = mockProdColl.FindOne(context.Background(), bson.M{"_id": productID}).Decode(&updated)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), *updated.Variants[0].Stock)
+	assert.Equal(t, int64(1), *updated.Variants[1].Stock)
+}
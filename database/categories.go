@@ -0,0 +1,140 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/models"
+	"ecommerce/taxonomy"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var (
+	ErrCategoryNotFound     = errors.New("category not found")
+	ErrCategorySlugTaken    = errors.New("category slug already exists")
+	ErrCantSaveCategory     = errors.New("cannot save category")
+	ErrCantListCategories   = errors.New("cannot list categories")
+	ErrCantUpdateCategories = errors.New("cannot update product categories")
+	ErrCantIndexCategories  = errors.New("cannot create category indexes")
+)
+func EnsureCategoryIndexes(ctx context.Context, categoryCollection *mongo.Collection) error {
+	index := mongo.IndexModel{Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetUnique(true)}
+	if _, err := categoryCollection.Indexes().CreateOne(ctx, index); err != nil {
+		log.Println(err)
+		return ErrCantIndexCategories
+	}
+	return nil
+}
+func CreateCategory(ctx context.Context, categoryCollection *mongo.Collection, category models.Category) (models.Category, error) {
+	category.ID = primitive.NewObjectID()
+	category.Created_At = time.Now()
+	category.Updated_At = category.Created_At
+	_, err := categoryCollection.InsertOne(ctx, category)
+	if mongo.IsDuplicateKeyError(err) {
+		return category, ErrCategorySlugTaken
+	}
+	if err != nil {
+		log.Println(err)
+		return category, ErrCantSaveCategory
+	}
+	return category, nil
+}
+func ListCategories(ctx context.Context, categoryCollection *mongo.Collection, filter bson.M) ([]models.Category, error) {
+	categories := make([]models.Category, 0)
+	cursor, err := categoryCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "name", Value: 1}}))
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantListCategories
+	}
+	if err = cursor.All(ctx, &categories); err != nil {
+		log.Println(err)
+		return nil, ErrCantListCategories
+	}
+	return categories, nil
+}
+func FindCategory(ctx context.Context, categoryCollection *mongo.Collection, filter bson.M) (models.Category, error) {
+	var category models.Category
+	err := categoryCollection.FindOne(ctx, filter).Decode(&category)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Println(err)
+		}
+		return category, ErrCategoryNotFound
+	}
+	return category, nil
+}
+func rebaseDescendants(ancestors []primitive.ObjectID, moved primitive.ObjectID, now time.Time) mongo.Pipeline {
+	tail := bson.M{"$slice": bson.A{"$ancestors", bson.M{"$add": bson.A{bson.M{"$indexOfArray": bson.A{"$ancestors", moved}}, 1}}, bson.M{"$size": "$ancestors"}}}
+	return mongo.Pipeline{{{Key: "$set", Value: bson.M{"ancestors": bson.M{"$concatArrays": bson.A{ancestors, bson.A{moved}, tail}}, "updated_at": now}}}}
+}
+func MoveCategory(ctx context.Context, categoryCollection *mongo.Collection, category models.Category, parent *models.Category) (models.Category, error) {
+	if err := taxonomy.CheckMove(category, parent); err != nil {
+		return category, err
+	}
+	previous := category
+	now := time.Now()
+	category.Parent_ID = nil
+	if parent != nil {
+		category.Parent_ID = &parent.ID
+	}
+	category.Ancestors = taxonomy.AncestorsFor(parent)
+	category.Updated_At = now
+	filter := bson.M{"_id": category.ID, "ancestors": previous.Ancestors}
+	update := bson.M{"$set": bson.M{"parent_id": category.Parent_ID, "ancestors": category.Ancestors, "updated_at": category.Updated_At}}
+	result, err := categoryCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return previous, ErrCantSaveCategory
+	}
+	if result.MatchedCount == 0 {
+		return previous, ErrCantSaveCategory
+	}
+	if parent != nil {
+		count, err := categoryCollection.CountDocuments(ctx, bson.M{"_id": parent.ID, "ancestors": parent.Ancestors})
+		if err != nil {
+			log.Println(err)
+		}
+		if err != nil || count == 0 {
+			restoreCategory(ctx, categoryCollection, previous)
+			return previous, ErrCantSaveCategory
+		}
+	}
+	if _, err = categoryCollection.UpdateMany(ctx, bson.M{"ancestors": category.ID}, rebaseDescendants(category.Ancestors, category.ID, now)); err != nil {
+		log.Println(err)
+		restoreCategory(ctx, categoryCollection, previous)
+		return previous, ErrCantSaveCategory
+	}
+	return category, nil
+}
+func restoreCategory(ctx context.Context, categoryCollection *mongo.Collection, category models.Category) {
+	update := bson.M{"$set": bson.M{"parent_id": category.Parent_ID, "ancestors": category.Ancestors, "updated_at": category.Updated_At}}
+	if _, err := categoryCollection.UpdateOne(ctx, bson.M{"_id": category.ID}, update); err != nil {
+		log.Println(err)
+	}
+}
+func SetProductCategories(ctx context.Context, prodCollection *mongo.Collection, productID primitive.ObjectID, categoryIDs []primitive.ObjectID) error {
+	result, err := prodCollection.UpdateOne(ctx, bson.M{"_id": productID}, bson.M{"$set": bson.M{"category_ids": categoryIDs}})
+	if err != nil {
+		log.Println(err)
+		return ErrCantUpdateCategories
+	}
+	if result.MatchedCount == 0 {
+		return ErrCantFindProduct
+	}
+	return nil
+}
+func CategoryProducts(ctx context.Context, prodCollection *mongo.Collection, categoryIDs []primitive.ObjectID) ([]models.Product, error) {
+	products := make([]models.Product, 0)
+	cursor, err := prodCollection.Find(ctx, bson.M{"category_ids": bson.M{"$in": categoryIDs}})
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantFindProduct
+	}
+	if err = cursor.All(ctx, &products); err != nil {
+		log.Println(err)
+		return nil, ErrCantDecodeProducts
+	}
+	return products, nil
+}
@@ -0,0 +1,161 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/models"
+	"ecommerce/promotions"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var (
+	ErrCouponNotFound   = errors.New("coupon not found")
+	ErrCouponExists     = errors.New("coupon code already exists")
+	ErrCantCreateCoupon = errors.New("cannot create coupon")
+	ErrCantRedeemCoupon = errors.New("cannot redeem coupon")
+	ErrCantIndexCoupons = errors.New("cannot create coupon indexes")
+)
+func EnsureCouponIndexes(ctx context.Context, couponCollection *mongo.Collection) error {
+	index := mongo.IndexModel{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true)}
+	if _, err := couponCollection.Indexes().CreateOne(ctx, index); err != nil {
+		log.Println(err)
+		return ErrCantIndexCoupons
+	}
+	return nil
+}
+func CreateCoupon(ctx context.Context, couponCollection *mongo.Collection, coupon models.Coupon) (models.Coupon, error) {
+	coupon.ID = primitive.NewObjectID()
+	coupon.Code = promotions.NormalizeCode(coupon.Code)
+	coupon.Uses = 0
+	coupon.Created_At = time.Now()
+	_, err := couponCollection.InsertOne(ctx, coupon)
+	if mongo.IsDuplicateKeyError(err) {
+		return coupon, ErrCouponExists
+	}
+	if err != nil {
+		log.Println(err)
+		return coupon, ErrCantCreateCoupon
+	}
+	return coupon, nil
+}
+func ListCoupons(ctx context.Context, couponCollection *mongo.Collection) ([]models.Coupon, error) {
+	coupons := make([]models.Coupon, 0)
+	cursor, err := couponCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCouponNotFound
+	}
+	if err = cursor.All(ctx, &coupons); err != nil {
+		log.Println(err)
+		return nil, ErrCouponNotFound
+	}
+	return coupons, nil
+}
+func FindCoupon(ctx context.Context, couponCollection *mongo.Collection, code string) (models.Coupon, error) {
+	var coupon models.Coupon
+	err := couponCollection.FindOne(ctx, bson.M{"code": promotions.NormalizeCode(code)}).Decode(&coupon)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println(err)
+		}
+		return coupon, ErrCouponNotFound
+	}
+	return coupon, nil
+}
+func CountCouponRedemptions(ctx context.Context, redemptionCollection *mongo.Collection, couponID primitive.ObjectID, userID string) (int, error) {
+	count, err := redemptionCollection.CountDocuments(ctx, bson.M{"coupon_id": couponID, "user_id": userID})
+	if err != nil {
+		log.Println(err)
+		return 0, ErrCantRedeemCoupon
+	}
+	return int(count), nil
+}
+func couponUsageID(couponID primitive.ObjectID, userID string) string {
+	return couponID.Hex() + ":" + userID
+}
+func claimUserUse(ctx context.Context, usageCollection *mongo.Collection, coupon models.Coupon, userID string) error {
+	if coupon.Max_Uses_Per_User == nil {
+		return nil
+	}
+	filter := bson.M{"_id": couponUsageID(coupon.ID, userID), "uses": bson.M{"$lt": *coupon.Max_Uses_Per_User}}
+	update := bson.M{"$inc": bson.M{"uses": 1}, "$setOnInsert": bson.M{"coupon_id": coupon.ID, "user_id": userID}}
+	_, err := usageCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return promotions.ErrCouponUserLimit
+	}
+	if err != nil {
+		log.Println(err)
+		return ErrCantRedeemCoupon
+	}
+	return nil
+}
+func releaseUserUse(ctx context.Context, usageCollection *mongo.Collection, coupon models.Coupon, userID string) {
+	if coupon.Max_Uses_Per_User == nil {
+		return
+	}
+	if _, err := usageCollection.UpdateOne(ctx, bson.M{"_id": couponUsageID(coupon.ID, userID)}, bson.M{"$inc": bson.M{"uses": -1}}); err != nil {
+		log.Println(err)
+	}
+}
+func releaseCouponUse(ctx context.Context, couponCollection *mongo.Collection, coupon models.Coupon) {
+	if _, err := couponCollection.UpdateOne(ctx, bson.M{"_id": coupon.ID}, bson.M{"$inc": bson.M{"uses": -1}}); err != nil {
+		log.Println(err)
+	}
+}
+func RedeemCoupon(ctx context.Context, couponCollection, usageCollection, redemptionCollection *mongo.Collection, coupon models.Coupon, redemption models.CouponRedemption) (models.CouponRedemption, error) {
+	if err := claimUserUse(ctx, usageCollection, coupon, redemption.User_ID); err != nil {
+		return redemption, err
+	}
+	filter := bson.M{"_id": coupon.ID, "active": true}
+	if coupon.Max_Uses != nil {
+		filter["uses"] = bson.M{"$lt": *coupon.Max_Uses}
+	}
+	result, err := couponCollection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"uses": 1}})
+	if err != nil || result.MatchedCount == 0 {
+		releaseUserUse(ctx, usageCollection, coupon, redemption.User_ID)
+		if err != nil {
+			log.Println(err)
+			return redemption, ErrCantRedeemCoupon
+		}
+		return redemption, promotions.ErrCouponUsedUp
+	}
+	redemption.ID = primitive.NewObjectID()
+	redemption.Coupon_ID = coupon.ID
+	redemption.Code = coupon.Code
+	redemption.Created_At = time.Now()
+	if _, err = redemptionCollection.InsertOne(ctx, redemption); err != nil {
+		log.Println(err)
+		releaseCouponUse(ctx, couponCollection, coupon)
+		releaseUserUse(ctx, usageCollection, coupon, redemption.User_ID)
+		return redemption, ErrCantRedeemCoupon
+	}
+	return redemption, nil
+}
+func ReleaseCoupon(ctx context.Context, couponCollection, usageCollection, redemptionCollection *mongo.Collection, coupon models.Coupon, redemption models.CouponRedemption) error {
+	if _, err := redemptionCollection.DeleteOne(ctx, bson.M{"_id": redemption.ID}); err != nil {
+		log.Println(err)
+		return ErrCantRedeemCoupon
+	}
+	releaseCouponUse(ctx, couponCollection, coupon)
+	releaseUserUse(ctx, usageCollection, coupon, redemption.User_ID)
+	return nil
+}
+func SetAppliedCoupon(ctx context.Context, userCollection *mongo.Collection, userID string, code *string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Println(err)
+		return ErrUserIDIsNotValid
+	}
+	update := bson.M{"$unset": bson.M{"applied_coupon": ""}}
+	if code != nil {
+		update = bson.M{"$set": bson.M{"applied_coupon": *code}}
+	}
+	if _, err = userCollection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		log.Println(err)
+		return ErrCantUpdateUser
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var (
+	ErrCantSaveRate  = errors.New("cannot save exchange rate")
+	ErrCantListRates = errors.New("cannot list exchange rates")
+)
+func CreateExchangeRate(ctx context.Context, rateCollection *mongo.Collection, rate models.ExchangeRate) (models.ExchangeRate, error) {
+	rate.ID = primitive.NewObjectID()
+	rate.Created_At = time.Now()
+	if rate.Effective_At.IsZero() {
+		rate.Effective_At = rate.Created_At
+	}
+	if _, err := rateCollection.InsertOne(ctx, rate); err != nil {
+		log.Println(err)
+		return rate, ErrCantSaveRate
+	}
+	return rate, nil
+}
+func ListExchangeRates(ctx context.Context, rateCollection *mongo.Collection, filter bson.M) ([]models.ExchangeRate, error) {
+	rates := make([]models.ExchangeRate, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "quote", Value: 1}, {Key: "effective_at", Value: -1}})
+	cursor, err := rateCollection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantListRates
+	}
+	if err = cursor.All(ctx, &rates); err != nil {
+		log.Println(err)
+		return nil, ErrCantListRates
+	}
+	return rates, nil
+}
+func EffectiveExchangeRates(ctx context.Context, rateCollection *mongo.Collection, base, quote string, at time.Time) ([]models.ExchangeRate, error) {
+	filter := bson.M{"base": base, "quote": quote, "effective_at": bson.M{"$lte": at}}
+	rates := make([]models.ExchangeRate, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "effective_at", Value: -1}, {Key: "created_at", Value: -1}}).SetLimit(1)
+	cursor, err := rateCollection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantListRates
+	}
+	if err = cursor.All(ctx, &rates); err != nil {
+		log.Println(err)
+		return nil, ErrCantListRates
+	}
+	return rates, nil
+}
@@ -0,0 +1,156 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/guestcart"
+	"ecommerce/models"
+	"ecommerce/variants"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var (
+	ErrCantFindGuestCart   = errors.New("guest cart not found")
+	ErrCantUpdateGuestCart = errors.New("cannot update guest cart")
+	ErrCantMergeGuestCart  = errors.New("cannot merge guest cart")
+	ErrCantIndexGuestCarts = errors.New("cannot create guest cart indexes")
+)
+func EnsureGuestCartIndexes(ctx context.Context, guestCollection *mongo.Collection) error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	if _, err := guestCollection.Indexes().CreateOne(ctx, index); err != nil {
+		log.Println(err)
+		return ErrCantIndexGuestCarts
+	}
+	return nil
+}
+func activeGuestCart(cartID primitive.ObjectID) bson.M {
+	return bson.M{"_id": cartID, "expires_at": bson.M{"$gt": time.Now()}}
+}
+func CreateGuestCart(ctx context.Context, guestCollection *mongo.Collection, ttl time.Duration) (models.GuestCart, error) {
+	now := time.Now()
+	cart := models.GuestCart{
+		ID:         primitive.NewObjectID(),
+		UserCart:   make([]models.ProductUser, 0),
+		Created_At: now,
+		Updated_At: now,
+		Expires_At: now.Add(ttl),
+	}
+	if _, err := guestCollection.InsertOne(ctx, cart); err != nil {
+		log.Println(err)
+		return cart, ErrCantUpdateGuestCart
+	}
+	return cart, nil
+}
+func GetGuestCart(ctx context.Context, guestCollection *mongo.Collection, cartID primitive.ObjectID) (models.GuestCart, error) {
+	var cart models.GuestCart
+	err := guestCollection.FindOne(ctx, activeGuestCart(cartID)).Decode(&cart)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println(err)
+		}
+		return cart, ErrCantFindGuestCart
+	}
+	return cart, nil
+}
+func AddProductToGuestCart(ctx context.Context, prodCollection, guestCollection *mongo.Collection, productID primitive.ObjectID, selection variants.Selection, cartID primitive.ObjectID, ttl time.Duration) error {
+	product, err := findCartLine(ctx, prodCollection, productID, selection)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	update := bson.M{
+		"$push": bson.M{"usercart": product},
+		"$set":  bson.M{"updated_at": now, "expires_at": now.Add(ttl)},
+	}
+	result, err := guestCollection.UpdateOne(ctx, activeGuestCart(cartID), update)
+	if err != nil {
+		log.Println(err)
+		return ErrCantUpdateGuestCart
+	}
+	if result.MatchedCount == 0 {
+		return ErrCantFindGuestCart
+	}
+	return nil
+}
+func RemoveGuestCartItem(ctx context.Context, prodCollection, guestCollection *mongo.Collection, productID primitive.ObjectID, sku string, cartID primitive.ObjectID) error {
+	line, err := cartLineFilter(ctx, prodCollection, productID, sku)
+	if err != nil {
+		return err
+	}
+	update := bson.M{
+		"$pull": bson.M{"usercart": line},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	result, err := guestCollection.UpdateOne(ctx, activeGuestCart(cartID), update)
+	if err != nil {
+		log.Println(err)
+		return ErrCantRemoveItem
+	}
+	if result.MatchedCount == 0 {
+		return ErrCantFindGuestCart
+	}
+	return nil
+}
+func MergeGuestCart(ctx context.Context, guestCollection, userCollection *mongo.Collection, cartID primitive.ObjectID, userID string, strategy string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Println(err)
+		return ErrUserIDIsNotValid
+	}
+	var cart models.GuestCart
+	err = guestCollection.FindOne(ctx, activeGuestCart(cartID)).Decode(&cart)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println(err)
+		}
+		return ErrCantFindGuestCart
+	}
+	if len(cart.UserCart) > 0 {
+		filter, update, err := guestCartMerge(ctx, userCollection, id, cart.UserCart, strategy)
+		if err != nil {
+			return err
+		}
+		result, err := userCollection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			log.Println(err)
+			return ErrCantMergeGuestCart
+		}
+		if result.MatchedCount == 0 {
+			return ErrCantMergeGuestCart
+		}
+	}
+	if _, err = guestCollection.DeleteOne(ctx, bson.M{"_id": cartID}); err != nil {
+		log.Println(err)
+	}
+	return nil
+}
+func guestCartMerge(ctx context.Context, userCollection *mongo.Collection, id primitive.ObjectID, lines []models.ProductUser, strategy string) (bson.M, bson.M, error) {
+	if strategy != guestcart.MergeNewest {
+		return bson.M{"_id": id}, bson.M{"$push": bson.M{"usercart": bson.M{"$each": lines}}}, nil
+	}
+	var stored struct {
+		UserCart bson.RawValue `bson:"usercart"`
+	}
+	err := userCollection.FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"usercart": 1})).Decode(&stored)
+	if err != nil {
+		log.Println(err)
+		return nil, nil, ErrCantMergeGuestCart
+	}
+	var usercart []models.ProductUser
+	filter := bson.M{"_id": id, "usercart": bson.M{"$exists": false}}
+	if stored.UserCart.Type != 0 {
+		if err = stored.UserCart.Unmarshal(&usercart); err != nil {
+			log.Println(err)
+			return nil, nil, ErrCantMergeGuestCart
+		}
+		filter["usercart"] = stored.UserCart
+	}
+	merged := guestcart.Merge(usercart, lines, strategy)
+	return filter, bson.M{"$set": bson.M{"usercart": merged}}, nil
+}
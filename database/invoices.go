@@ -0,0 +1,157 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/invoicing"
+	"ecommerce/models"
+	"ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+const maxInvoiceAttempts = 5
+var (
+	ErrInvoiceNotFound   = errors.New("invoice not found")
+	ErrCantIssueInvoice  = errors.New("cannot issue invoice")
+	ErrCantListInvoices  = errors.New("cannot list invoices")
+	ErrCantIndexInvoices = errors.New("cannot create invoice indexes")
+	ErrCantCreditInvoice = errors.New("cannot record credit on invoice")
+)
+func EnsureInvoiceIndexes(ctx context.Context, invoiceCollection *mongo.Collection) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "kind", Value: 1}, {Key: "year", Value: 1}, {Key: "sequence", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"kind": models.InvoiceKindInvoice}),
+		},
+	}
+	if _, err := invoiceCollection.Indexes().CreateMany(ctx, indexes); err != nil {
+		log.Println(err)
+		return ErrCantIndexInvoices
+	}
+	return nil
+}
+func FindOrderInvoice(ctx context.Context, invoiceCollection *mongo.Collection, orderID primitive.ObjectID) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := invoiceCollection.FindOne(ctx, bson.M{"order_id": orderID, "kind": models.InvoiceKindInvoice}).Decode(&invoice)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return invoice, ErrInvoiceNotFound
+	}
+	if err != nil {
+		log.Println(err)
+		return invoice, ErrInvoiceNotFound
+	}
+	return invoice, nil
+}
+func ListCreditNotes(ctx context.Context, invoiceCollection *mongo.Collection, orderID primitive.ObjectID) ([]models.Invoice, error) {
+	notes := make([]models.Invoice, 0)
+	filter := bson.M{"order_id": orderID, "kind": models.InvoiceKindCreditNote}
+	cursor, err := invoiceCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "issued_at", Value: 1}}))
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantListInvoices
+	}
+	if err = cursor.All(ctx, &notes); err != nil {
+		log.Println(err)
+		return nil, ErrCantListInvoices
+	}
+	return notes, nil
+}
+func FindInvoiceByNumber(ctx context.Context, invoiceCollection *mongo.Collection, number string) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := invoiceCollection.FindOne(ctx, bson.M{"number": number}).Decode(&invoice)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Println(err)
+		}
+		return invoice, ErrInvoiceNotFound
+	}
+	return invoice, nil
+}
+func nextInvoiceSequence(ctx context.Context, invoiceCollection *mongo.Collection, kind string, year int) (int64, error) {
+	var last models.Invoice
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+	err := invoiceCollection.FindOne(ctx, bson.M{"kind": kind, "year": year}, opts).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, err
+	}
+	return last.Sequence + 1, nil
+}
+func IssueInvoice(ctx context.Context, invoiceCollection *mongo.Collection, invoice models.Invoice) (models.Invoice, error) {
+	if invoice.Issued_At.IsZero() {
+		invoice.Issued_At = time.Now().UTC()
+	}
+	invoice.Year = invoice.Issued_At.Year()
+	for attempt := 0; attempt < maxInvoiceAttempts; attempt++ {
+		sequence, err := nextInvoiceSequence(ctx, invoiceCollection, invoice.Kind, invoice.Year)
+		if err != nil {
+			log.Println(err)
+			return invoice, ErrCantIssueInvoice
+		}
+		invoice.ID = primitive.NewObjectID()
+		invoice.Sequence = sequence
+		invoice.Number = invoicing.Number(invoice.Kind, invoice.Year, sequence)
+		_, err = invoiceCollection.InsertOne(ctx, invoice)
+		if err == nil {
+			return invoice, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			log.Println(err)
+			return invoice, ErrCantIssueInvoice
+		}
+		if invoice.Kind == models.InvoiceKindInvoice {
+			if existing, err := FindOrderInvoice(ctx, invoiceCollection, invoice.Order_ID); err == nil {
+				return existing, nil
+			}
+		}
+	}
+	return invoice, ErrCantIssueInvoice
+}
+func DeleteInvoice(ctx context.Context, invoiceCollection *mongo.Collection, invoiceID primitive.ObjectID) error {
+	if _, err := invoiceCollection.DeleteOne(ctx, bson.M{"_id": invoiceID}); err != nil {
+		log.Println(err)
+		return ErrCantIssueInvoice
+	}
+	return nil
+}
+func ReserveCredit(ctx context.Context, invoiceCollection *mongo.Collection, invoice models.Invoice, credits []models.Invoice, amount money.Money) error {
+	if len(credits) > 0 {
+		outstanding, err := invoicing.Outstanding(invoice, credits)
+		if err != nil {
+			return err
+		}
+		credited, err := invoice.Total.Sub(outstanding)
+		if err != nil {
+			return err
+		}
+		if _, err = invoiceCollection.UpdateOne(ctx, bson.M{"_id": invoice.ID, "credited": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"credited": credited}}); err != nil {
+			log.Println(err)
+			return ErrCantCreditInvoice
+		}
+	}
+	filter := bson.M{
+		"_id":   invoice.ID,
+		"$expr": bson.M{"$lte": bson.A{bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$credited.amount", 0}}, amount.Amount}}, "$total.amount"}},
+	}
+	update := bson.M{"$inc": bson.M{"credited.amount": amount.Amount}, "$set": bson.M{"credited.currency": amount.Currency}}
+	result, err := invoiceCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return ErrCantCreditInvoice
+	}
+	if result.MatchedCount == 0 {
+		return invoicing.ErrCreditTooLarge
+	}
+	return nil
+}
+func ReleaseCredit(ctx context.Context, invoiceCollection *mongo.Collection, invoice models.Invoice, amount money.Money) {
+	if _, err := invoiceCollection.UpdateOne(ctx, bson.M{"_id": invoice.ID}, bson.M{"$inc": bson.M{"credited.amount": -amount.Amount}}); err != nil {
+		log.Println(err)
+	}
+}
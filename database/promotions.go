@@ -0,0 +1,71 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var (
+	ErrRuleNotFound  = errors.New("promotion rule not found")
+	ErrCantSaveRule  = errors.New("cannot save promotion rule")
+	ErrCantListRules = errors.New("cannot list promotion rules")
+)
+func CreatePromotionRule(ctx context.Context, ruleCollection *mongo.Collection, rule models.PromotionRule) (models.PromotionRule, error) {
+	rule.ID = primitive.NewObjectID()
+	rule.Created_At = time.Now()
+	rule.Updated_At = rule.Created_At
+	if _, err := ruleCollection.InsertOne(ctx, rule); err != nil {
+		log.Println(err)
+		return rule, ErrCantSaveRule
+	}
+	return rule, nil
+}
+func ListPromotionRules(ctx context.Context, ruleCollection *mongo.Collection, activeOnly bool) ([]models.PromotionRule, error) {
+	filter := bson.M{}
+	if activeOnly {
+		filter["active"] = true
+	}
+	rules := make([]models.PromotionRule, 0)
+	cursor, err := ruleCollection.Find(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantListRules
+	}
+	if err = cursor.All(ctx, &rules); err != nil {
+		log.Println(err)
+		return nil, ErrCantListRules
+	}
+	return rules, nil
+}
+func UpdatePromotionRule(ctx context.Context, ruleCollection *mongo.Collection, rule models.PromotionRule) (models.PromotionRule, error) {
+	var existing models.PromotionRule
+	if err := ruleCollection.FindOne(ctx, bson.M{"_id": rule.ID}).Decode(&existing); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println(err)
+			return rule, ErrCantSaveRule
+		}
+		return rule, ErrRuleNotFound
+	}
+	rule.Created_At = existing.Created_At
+	rule.Updated_At = time.Now()
+	if _, err := ruleCollection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule); err != nil {
+		log.Println(err)
+		return rule, ErrCantSaveRule
+	}
+	return rule, nil
+}
+func DeletePromotionRule(ctx context.Context, ruleCollection *mongo.Collection, ruleID primitive.ObjectID) error {
+	result, err := ruleCollection.DeleteOne(ctx, bson.M{"_id": ruleID})
+	if err != nil {
+		log.Println(err)
+		return ErrCantSaveRule
+	}
+	if result.DeletedCount == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var (
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrShipmentNotFound    = errors.New("shipment not found")
+	ErrShipmentChanged     = errors.New("shipment was updated concurrently")
+	ErrCantSaveShipment    = errors.New("cannot save shipment")
+	ErrCantListShipments   = errors.New("cannot list shipments")
+	ErrCantSaveOrderStatus = errors.New("cannot update order status")
+	ErrCantIndexShipments  = errors.New("cannot create shipment indexes")
+)
+func EnsureShipmentIndexes(ctx context.Context, shipmentCollection *mongo.Collection) error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "order_id", Value: 1}, {Key: "sequence", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"sequence": bson.M{"$gt": 0}}),
+	}
+	if _, err := shipmentCollection.Indexes().CreateOne(ctx, index); err != nil {
+		log.Println(err)
+		return ErrCantIndexShipments
+	}
+	return nil
+}
+func FindOrder(ctx context.Context, userCollection *mongo.Collection, orderID primitive.ObjectID) (models.User, models.Order, error) {
+	var user models.User
+	err := userCollection.FindOne(ctx, bson.M{"orders._id": orderID}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return user, models.Order{}, ErrOrderNotFound
+	}
+	if err != nil {
+		log.Println(err)
+		return user, models.Order{}, ErrOrderNotFound
+	}
+	for _, order := range user.Order_Status {
+		if order.Order_ID == orderID {
+			return user, order, nil
+		}
+	}
+	return user, models.Order{}, ErrOrderNotFound
+}
+func SetOrderStatus(ctx context.Context, userCollection *mongo.Collection, order models.Order) error {
+	update := bson.M{"orders.$.status": order.Status}
+	if order.Shipped_At != nil {
+		update["orders.$.shipped_at"] = order.Shipped_At
+	}
+	if order.Delivered_At != nil {
+		update["orders.$.delivered_at"] = order.Delivered_At
+	}
+	_, err := userCollection.UpdateOne(ctx, bson.M{"orders._id": order.Order_ID}, bson.M{"$set": update})
+	if err != nil {
+		log.Println(err)
+		return ErrCantSaveOrderStatus
+	}
+	return nil
+}
+func CreateShipment(ctx context.Context, shipmentCollection *mongo.Collection, shipment models.Shipment, existing []models.Shipment) (models.Shipment, error) {
+	shipment.ID = primitive.NewObjectID()
+	shipment.Sequence = int64(len(existing)) + 1
+	shipment.Created_At = time.Now()
+	shipment.Updated_At = shipment.Created_At
+	shipment.Events = []models.ShipmentEvent{{Status: shipment.Status, At: shipment.Created_At}}
+	_, err := shipmentCollection.InsertOne(ctx, shipment)
+	if mongo.IsDuplicateKeyError(err) {
+		return shipment, ErrShipmentChanged
+	}
+	if err != nil {
+		log.Println(err)
+		return shipment, ErrCantSaveShipment
+	}
+	return shipment, nil
+}
+func ListShipments(ctx context.Context, shipmentCollection *mongo.Collection, orderID primitive.ObjectID) ([]models.Shipment, error) {
+	shipments := make([]models.Shipment, 0)
+	cursor, err := shipmentCollection.Find(ctx, bson.M{"order_id": orderID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantListShipments
+	}
+	if err = cursor.All(ctx, &shipments); err != nil {
+		log.Println(err)
+		return nil, ErrCantListShipments
+	}
+	return shipments, nil
+}
+func FindShipment(ctx context.Context, shipmentCollection *mongo.Collection, shipmentID primitive.ObjectID) (models.Shipment, error) {
+	var shipment models.Shipment
+	err := shipmentCollection.FindOne(ctx, bson.M{"_id": shipmentID}).Decode(&shipment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return shipment, ErrShipmentNotFound
+	}
+	if err != nil {
+		log.Println(err)
+		return shipment, ErrShipmentNotFound
+	}
+	return shipment, nil
+}
+func UpdateShipmentStatus(ctx context.Context, shipmentCollection *mongo.Collection, shipment models.Shipment, event models.ShipmentEvent) (models.Shipment, error) {
+	filter := bson.M{"_id": shipment.ID, "status": shipment.Status}
+	update := bson.M{
+		"$set":  bson.M{"status": event.Status, "updated_at": event.At},
+		"$push": bson.M{"events": event},
+	}
+	result, err := shipmentCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return shipment, ErrCantSaveShipment
+	}
+	if result.MatchedCount == 0 {
+		return shipment, ErrShipmentChanged
+	}
+	shipment.Status = event.Status
+	shipment.Updated_At = event.At
+	shipment.Events = append(shipment.Events, event)
+	return shipment, nil
+}
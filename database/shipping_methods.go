@@ -0,0 +1,68 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var (
+	ErrCantSaveShippingMethod   = errors.New("cannot save shipping method")
+	ErrCantListShippingMethods  = errors.New("cannot list shipping methods")
+	ErrShippingMethodNotFound   = errors.New("shipping method not found")
+	ErrShippingCodeTaken        = errors.New("shipping method code already exists")
+	ErrCantIndexShippingMethods = errors.New("cannot create shipping method indexes")
+)
+func EnsureShippingMethodIndexes(ctx context.Context, methodCollection *mongo.Collection) error {
+	index := mongo.IndexModel{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true)}
+	if _, err := methodCollection.Indexes().CreateOne(ctx, index); err != nil {
+		log.Println(err)
+		return ErrCantIndexShippingMethods
+	}
+	return nil
+}
+func CreateShippingMethod(ctx context.Context, methodCollection *mongo.Collection, method models.ShippingMethod) (models.ShippingMethod, error) {
+	method.ID = primitive.NewObjectID()
+	method.Created_At = time.Now()
+	_, err := methodCollection.InsertOne(ctx, method)
+	if mongo.IsDuplicateKeyError(err) {
+		return method, ErrShippingCodeTaken
+	}
+	if err != nil {
+		log.Println(err)
+		return method, ErrCantSaveShippingMethod
+	}
+	return method, nil
+}
+func ListShippingMethods(ctx context.Context, methodCollection *mongo.Collection, activeOnly bool) ([]models.ShippingMethod, error) {
+	filter := bson.M{}
+	if activeOnly {
+		filter["active"] = true
+	}
+	methods := make([]models.ShippingMethod, 0)
+	cursor, err := methodCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "code", Value: 1}}))
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantListShippingMethods
+	}
+	if err = cursor.All(ctx, &methods); err != nil {
+		log.Println(err)
+		return nil, ErrCantListShippingMethods
+	}
+	return methods, nil
+}
+func DeleteShippingMethod(ctx context.Context, methodCollection *mongo.Collection, methodID primitive.ObjectID) error {
+	result, err := methodCollection.DeleteOne(ctx, bson.M{"_id": methodID})
+	if err != nil {
+		log.Println(err)
+		return ErrCantSaveShippingMethod
+	}
+	if result.DeletedCount == 0 {
+		return ErrShippingMethodNotFound
+	}
+	return nil
+}
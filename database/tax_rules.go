@@ -0,0 +1,51 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var (
+	ErrCantSaveTaxRule  = errors.New("cannot save tax rule")
+	ErrCantListTaxRules = errors.New("cannot list tax rules")
+	ErrTaxRuleNotFound  = errors.New("tax rule not found")
+)
+func CreateTaxRule(ctx context.Context, taxCollection *mongo.Collection, rule models.TaxRule) (models.TaxRule, error) {
+	rule.ID = primitive.NewObjectID()
+	rule.Created_At = time.Now()
+	if _, err := taxCollection.InsertOne(ctx, rule); err != nil {
+		log.Println(err)
+		return rule, ErrCantSaveTaxRule
+	}
+	return rule, nil
+}
+func ListTaxRules(ctx context.Context, taxCollection *mongo.Collection, filter bson.M) ([]models.TaxRule, error) {
+	rules := make([]models.TaxRule, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "country", Value: 1}, {Key: "region", Value: 1}, {Key: "category", Value: 1}, {Key: "created_at", Value: -1}})
+	cursor, err := taxCollection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantListTaxRules
+	}
+	if err = cursor.All(ctx, &rules); err != nil {
+		log.Println(err)
+		return nil, ErrCantListTaxRules
+	}
+	return rules, nil
+}
+func DeleteTaxRule(ctx context.Context, taxCollection *mongo.Collection, ruleID primitive.ObjectID) error {
+	result, err := taxCollection.DeleteOne(ctx, bson.M{"_id": ruleID})
+	if err != nil {
+		log.Println(err)
+		return ErrCantSaveTaxRule
+	}
+	if result.DeletedCount == 0 {
+		return ErrTaxRuleNotFound
+	}
+	return nil
+}
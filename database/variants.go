@@ -0,0 +1,53 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"ecommerce/models"
+	"ecommerce/variants"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+var (
+	ErrSKUTaken           = errors.New("sku is already used by another product")
+	ErrCantUpdateVariants = errors.New("cannot update product variants")
+	ErrCantIndexVariants  = errors.New("cannot create variant indexes")
+)
+func EnsureVariantIndexes(ctx context.Context, prodCollection *mongo.Collection) error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "variants.sku", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"variants.sku": bson.M{"$exists": true}}),
+	}
+	if _, err := prodCollection.Indexes().CreateOne(ctx, index); err != nil {
+		log.Println(err)
+		return ErrCantIndexVariants
+	}
+	return nil
+}
+func SetProductVariants(ctx context.Context, prodCollection *mongo.Collection, productID primitive.ObjectID, request models.ProductVariants) (models.Product, error) {
+	var product models.Product
+	err := prodCollection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		return product, ErrCantFindProduct
+	}
+	if err != nil {
+		log.Println(err)
+		return product, ErrCantUpdateVariants
+	}
+	product.Options = request.Options
+	product.Variants = request.Variants
+	if err = variants.Validate(product); err != nil {
+		return product, err
+	}
+	update := bson.M{"$set": bson.M{"options": product.Options, "variants": product.Variants}}
+	if _, err = prodCollection.UpdateOne(ctx, bson.M{"_id": productID}, update); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return product, ErrSKUTaken
+		}
+		log.Println(err)
+		return product, ErrCantUpdateVariants
+	}
+	return product, nil
+}
@@ -0,0 +1,210 @@
+package database
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"ecommerce/models"
+	"ecommerce/money"
+	"ecommerce/variants"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+var (
+	ErrCantFindWishlist   = errors.New("cannot get wishlist")
+	ErrCantUpdateWishlist = errors.New("cannot update wishlist")
+	ErrAlreadyInWishlist  = errors.New("product is already in the wishlist")
+	ErrNotInWishlist      = errors.New("product is not in the wishlist")
+	ErrNotInCart          = errors.New("product is not in the cart")
+)
+func findCartProduct(ctx context.Context, prodCollection *mongo.Collection, productID primitive.ObjectID) (models.ProductUser, error) {
+	var product models.ProductUser
+	err := prodCollection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+	if err != nil {
+		log.Println(err)
+		return product, ErrCantFindProduct
+	}
+	return product, nil
+}
+func findCartLine(ctx context.Context, prodCollection *mongo.Collection, productID primitive.ObjectID, selection variants.Selection) (models.ProductUser, error) {
+	var product models.Product
+	err := prodCollection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+	if err != nil {
+		log.Println(err)
+		return models.ProductUser{}, ErrCantFindProduct
+	}
+	variant, err := variants.Resolve(product, selection)
+	if err != nil {
+		return models.ProductUser{}, err
+	}
+	return variants.Line(product, variant)
+}
+func newWishlistItem(product models.ProductUser) models.WishlistItem {
+	return models.WishlistItem{
+		Product_ID:   product.Product_ID,
+		Product_Name: product.Product_Name,
+		Image:        product.Image,
+		Price_At_Add: product.Price,
+		Added_At:     time.Now(),
+	}
+}
+func AddProductToWishlist(ctx context.Context, prodCollection, userCollection *mongo.Collection, productID primitive.ObjectID, userID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Println(err)
+		return ErrUserIDIsNotValid
+	}
+	product, err := findCartProduct(ctx, prodCollection, productID)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": id, "wishlist.product_id": bson.M{"$ne": productID}}
+	update := bson.M{"$push": bson.M{"wishlist": newWishlistItem(product)}}
+	result, err := userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return ErrCantUpdateWishlist
+	}
+	if result.MatchedCount == 0 {
+		return ErrAlreadyInWishlist
+	}
+	return nil
+}
+func RemoveWishlistItem(ctx context.Context, userCollection *mongo.Collection, productID primitive.ObjectID, userID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Println(err)
+		return ErrUserIDIsNotValid
+	}
+	filter := bson.M{"_id": id, "wishlist.product_id": productID}
+	update := bson.M{"$pull": bson.M{"wishlist": bson.M{"product_id": productID}}}
+	result, err := userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return ErrCantUpdateWishlist
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotInWishlist
+	}
+	return nil
+}
+func GetWishlist(ctx context.Context, prodCollection, userCollection *mongo.Collection, userID string) ([]models.WishlistItem, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Println(err)
+		return nil, ErrUserIDIsNotValid
+	}
+	var founduser models.User
+	err = userCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&founduser)
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantFindWishlist
+	}
+	wishlist := founduser.Wishlist
+	if wishlist == nil {
+		wishlist = make([]models.WishlistItem, 0)
+	}
+	if len(wishlist) == 0 {
+		return wishlist, nil
+	}
+	productIDs := make([]primitive.ObjectID, 0, len(wishlist))
+	for _, item := range wishlist {
+		productIDs = append(productIDs, item.Product_ID)
+	}
+	cursor, err := prodCollection.Find(ctx, bson.M{"_id": bson.M{"$in": productIDs}})
+	if err != nil {
+		log.Println(err)
+		return nil, ErrCantFindProduct
+	}
+	var products []models.ProductUser
+	if err = cursor.All(ctx, &products); err != nil {
+		log.Println(err)
+		return nil, ErrCantDecodeProducts
+	}
+	prices := make(map[primitive.ObjectID]money.Money, len(products))
+	for _, product := range products {
+		prices[product.Product_ID] = product.Price
+	}
+	MarkPriceDrops(wishlist, prices)
+	return wishlist, nil
+}
+func MarkPriceDrops(wishlist []models.WishlistItem, prices map[primitive.ObjectID]money.Money) {
+	for i := range wishlist {
+		price, ok := prices[wishlist[i].Product_ID]
+		if !ok {
+			continue
+		}
+		wishlist[i].Current_Price = &price
+		wishlist[i].Price_Dropped = price.Currency == wishlist[i].Price_At_Add.Currency && price.LessThan(wishlist[i].Price_At_Add)
+	}
+}
+func pullOneCartLine(productID primitive.ObjectID, sku string) bson.M {
+	matches := bson.M{"$map": bson.M{"input": "$usercart", "in": bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{"$$this._id", productID}},
+		bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$$this.variant_sku", ""}}, sku}},
+	}}}}
+	keep := bson.M{"$filter": bson.M{"input": bson.M{"$range": bson.A{0, bson.M{"$size": "$usercart"}}}, "cond": bson.M{"$ne": bson.A{"$$this", "$$index"}}}}
+	return bson.M{"$let": bson.M{
+		"vars": bson.M{"index": bson.M{"$indexOfArray": bson.A{matches, true}}},
+		"in":   bson.M{"$map": bson.M{"input": keep, "in": bson.M{"$arrayElemAt": bson.A{"$usercart", "$$this"}}}},
+	}}
+}
+func SaveCartItemForLater(ctx context.Context, prodCollection, userCollection *mongo.Collection, productID primitive.ObjectID, userID string, selection variants.Selection) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Println(err)
+		return ErrUserIDIsNotValid
+	}
+	line, err := findCartLine(ctx, prodCollection, productID, selection)
+	if err != nil {
+		return err
+	}
+	inCart := bson.M{"_id": productID, "variant_sku": line.Variant_SKU}
+	if line.Variant_SKU == "" {
+		inCart["variant_sku"] = bson.M{"$in": bson.A{nil, ""}}
+	}
+	wishlist := bson.M{"$ifNull": bson.A{"$wishlist", bson.A{}}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"usercart": pullOneCartLine(productID, line.Variant_SKU),
+		"wishlist": bson.M{"$cond": bson.A{
+			bson.M{"$in": bson.A{productID, bson.M{"$ifNull": bson.A{"$wishlist.product_id", bson.A{}}}}},
+			wishlist,
+			bson.M{"$concatArrays": bson.A{wishlist, bson.A{bson.M{"$literal": newWishlistItem(line)}}}},
+		}},
+	}}}}
+	result, err := userCollection.UpdateOne(ctx, bson.M{"_id": id, "usercart": bson.M{"$elemMatch": inCart}}, update)
+	if err != nil {
+		log.Println(err)
+		return ErrCantUpdateWishlist
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotInCart
+	}
+	return nil
+}
+func MoveWishlistItemToCart(ctx context.Context, prodCollection, userCollection *mongo.Collection, productID primitive.ObjectID, userID string, selection variants.Selection) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Println(err)
+		return ErrUserIDIsNotValid
+	}
+	product, err := findCartLine(ctx, prodCollection, productID, selection)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": id, "wishlist.product_id": productID}
+	update := bson.M{
+		"$pull": bson.M{"wishlist": bson.M{"product_id": productID}},
+		"$push": bson.M{"usercart": product},
+	}
+	result, err := userCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return ErrCantUpdateUser
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotInWishlist
+	}
+	return nil
+}
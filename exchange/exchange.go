@@ -0,0 +1,168 @@
+package exchange
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+	"ecommerce/config"
+	"ecommerce/models"
+	"ecommerce/money"
+)
+const Header = "X-Currency"
+var (
+	ErrNoRate      = errors.New("no exchange rate available")
+	ErrInvalidRate = errors.New("exchange rate must be a positive decimal")
+)
+var regionCurrencies = map[string]string{
+	"US": "USD", "CA": "CAD", "MX": "MXN", "BR": "BRL", "GB": "GBP", "IE": "EUR",
+	"DE": "EUR", "FR": "EUR", "ES": "EUR", "IT": "EUR", "NL": "EUR", "BE": "EUR",
+	"AT": "EUR", "PT": "EUR", "FI": "EUR", "CH": "CHF", "SE": "SEK", "NO": "NOK",
+	"DK": "DKK", "PL": "PLN", "JP": "JPY", "CN": "CNY", "KR": "KRW", "IN": "INR",
+	"AU": "AUD", "NZ": "NZD", "SG": "SGD", "HK": "HKD", "VN": "VND", "ZA": "ZAR",
+}
+func ParseRate(rate string) (*big.Rat, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || value.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return value, nil
+}
+func Supported() []string {
+	base := money.DefaultCurrency()
+	supported := []string{base}
+	for _, code := range strings.Split(config.String("SUPPORTED_CURRENCIES", ""), ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if money.ValidCurrency(code) && code != base {
+			supported = append(supported, code)
+		}
+	}
+	return supported
+}
+func Negotiate(query, header, acceptLanguage string, supported []string, fallback string) string {
+	allowed := make(map[string]bool, len(supported))
+	for _, code := range supported {
+		allowed[code] = true
+	}
+	for _, candidate := range []string{query, header} {
+		if code := strings.ToUpper(strings.TrimSpace(candidate)); allowed[code] {
+			return code
+		}
+	}
+	for _, tag := range languageTags(acceptLanguage) {
+		_, region, found := strings.Cut(tag, "-")
+		if !found {
+			continue
+		}
+		if code := regionCurrencies[strings.ToUpper(region)]; allowed[code] {
+			return code
+		}
+	}
+	return fallback
+}
+func languageTags(header string) []string {
+	type weighted struct {
+		tag    string
+		weight float64
+	}
+	tags := make([]weighted, 0)
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, ok := new(big.Rat).SetString(q); ok {
+				weight, _ = value.Float64()
+			}
+		}
+		tags = append(tags, weighted{tag: strings.ReplaceAll(tag, "_", "-"), weight: weight})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].weight > tags[j].weight })
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		result = append(result, tag.tag)
+	}
+	return result
+}
+func Select(rates []models.ExchangeRate, base, quote string, at time.Time) (models.ExchangeRate, bool) {
+	var selected models.ExchangeRate
+	found := false
+	for _, rate := range rates {
+		if rate.Base != base || rate.Quote != quote || rate.Effective_At.After(at) {
+			continue
+		}
+		if !found || rate.Effective_At.After(selected.Effective_At) || (rate.Effective_At.Equal(selected.Effective_At) && rate.Created_At.After(selected.Created_At)) {
+			selected = rate
+			found = true
+		}
+	}
+	return selected, found
+}
+func Convert(amount money.Money, to string, rate string) (money.Money, error) {
+	if amount.Currency == to {
+		return amount, nil
+	}
+	value, err := ParseRate(rate)
+	if err != nil {
+		return amount, err
+	}
+	shift := money.Exponent(to) - money.Exponent(amount.Currency)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil)
+	if shift >= 0 {
+		value.Mul(value, new(big.Rat).SetInt(scale))
+	} else {
+		value.Quo(value, new(big.Rat).SetInt(scale))
+	}
+	if !value.Num().IsInt64() || !value.Denom().IsInt64() {
+		return amount, money.ErrOverflow
+	}
+	converted, err := amount.MulRat(value.Num().Int64(), value.Denom().Int64(), money.RoundHalfEven)
+	if err != nil {
+		return amount, err
+	}
+	converted.Currency = to
+	return converted, nil
+}
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+type Converter struct {
+	Base  string
+	To    string
+	Rate  models.ExchangeRate
+	Found bool
+}
+func NewConverter(rates []models.ExchangeRate, base, to string, at time.Time) (Converter, error) {
+	converter := Converter{Base: base, To: to}
+	if base == to {
+		converter.Rate = models.ExchangeRate{Base: base, Quote: to, Rate: "1", Effective_At: at}
+		converter.Found = true
+		return converter, nil
+	}
+	converter.Rate, converter.Found = Select(rates, base, to, at)
+	if !converter.Found {
+		return converter, ErrNoRate
+	}
+	return converter, nil
+}
+func (c Converter) Money(amount money.Money) (money.Money, error) {
+	if amount.Currency == c.To || (amount.Currency == "" && amount.Amount == 0) {
+		amount.Currency = c.To
+		return amount, nil
+	}
+	if amount.Currency != c.Base {
+		return amount, money.ErrCurrencyMismatch
+	}
+	return Convert(amount, c.To, c.Rate.Rate)
+}
+func (c Converter) Price(product models.Product, basePrice money.Money) (money.Money, error) {
+	if override, ok := product.Price_Overrides[c.To]; ok && override.Currency == c.To {
+		return override, nil
+	}
+	return c.Money(basePrice)
+}
@@ -0,0 +1,66 @@
+package exchange
+import (
+	"testing"
+	"time"
+	"ecommerce/models"
+	"ecommerce/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+func TestConvert(t *testing.T) {
+	eur, err := Convert(money.New(1000, "USD"), "EUR", "0.9215")
+	require.NoError(t, err)
+	assert.Equal(t, money.New(922, "EUR"), eur)
+	yen, err := Convert(money.New(1999, "USD"), "JPY", "151.37")
+	require.NoError(t, err)
+	assert.Equal(t, money.New(3026, "JPY"), yen)
+	dollars, err := Convert(money.New(3026, "JPY"), "USD", "0.0066")
+	require.NoError(t, err)
+	assert.Equal(t, money.New(1997, "USD"), dollars)
+	_, err = Convert(money.New(1, "USD"), "EUR", "-1")
+	assert.ErrorIs(t, err, ErrInvalidRate)
+}
+func TestSelectUsesLatestEffectiveRate(t *testing.T) {
+	now := time.Now()
+	rates := []models.ExchangeRate{
+		{Base: "USD", Quote: "EUR", Rate: "0.90", Effective_At: now.Add(-48 * time.Hour)},
+		{Base: "USD", Quote: "EUR", Rate: "0.92", Effective_At: now.Add(-time.Hour)},
+		{Base: "USD", Quote: "EUR", Rate: "0.95", Effective_At: now.Add(time.Hour)},
+		{Base: "USD", Quote: "GBP", Rate: "0.80", Effective_At: now.Add(-time.Hour)},
+	}
+	rate, ok := Select(rates, "USD", "EUR", now)
+	require.True(t, ok)
+	assert.Equal(t, "0.92", rate.Rate)
+	rate, ok = Select(rates, "USD", "EUR", now.Add(-24*time.Hour))
+	require.True(t, ok)
+	assert.Equal(t, "0.90", rate.Rate)
+	_, ok = Select(rates, "USD", "JPY", now)
+	assert.False(t, ok)
+}
+func TestNegotiate(t *testing.T) {
+	supported := []string{"USD", "EUR", "GBP"}
+	assert.Equal(t, "EUR", Negotiate("eur", "GBP", "en-GB", supported, "USD"))
+	assert.Equal(t, "GBP", Negotiate("", "gbp", "de-DE", supported, "USD"))
+	assert.Equal(t, "EUR", Negotiate("CHF", "", "fr-CH;q=0.9, de-DE, en;q=0.5", supported, "USD"))
+	assert.Equal(t, "USD", Negotiate("", "", "ja-JP", supported, "USD"))
+}
+func TestConverterPrefersOverrides(t *testing.T) {
+	now := time.Now()
+	rates := []models.ExchangeRate{{Base: "USD", Quote: "EUR", Rate: "0.5", Effective_At: now.Add(-time.Hour)}}
+	converter, err := NewConverter(rates, "USD", "EUR", now)
+	require.NoError(t, err)
+	product := models.Product{Price_Overrides: map[string]money.Money{"EUR": money.New(999, "EUR")}}
+	price, err := converter.Price(product, money.New(1000, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, money.New(999, "EUR"), price)
+	price, err = converter.Price(models.Product{}, money.New(1000, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, money.New(500, "EUR"), price)
+	_, err = converter.Money(money.New(1000, "GBP"))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+	_, err = NewConverter(rates, "USD", "GBP", now)
+	assert.ErrorIs(t, err, ErrNoRate)
+	same, err := NewConverter(nil, "USD", "USD", now)
+	require.NoError(t, err)
+	assert.Equal(t, "1", same.Rate.Rate)
+}
@@ -0,0 +1,95 @@
+package fulfillment
+import (
+	"errors"
+	"ecommerce/cart"
+	"ecommerce/models"
+)
+var (
+	ErrEmptyShipment     = errors.New("shipment must contain at least one line")
+	ErrExceedsOrdered    = errors.New("shipment quantity exceeds what remains to be fulfilled")
+	ErrInvalidTransition = errors.New("shipment status change is not allowed")
+)
+var transitions = map[string][]string{
+	models.ShipmentPending:   {models.ShipmentShipped, models.ShipmentCancelled},
+	models.ShipmentShipped:   {models.ShipmentInTransit, models.ShipmentDelivered},
+	models.ShipmentInTransit: {models.ShipmentDelivered},
+}
+func CanTransition(from, to string) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+func Ordered(order models.Order) map[cart.LineKey]int {
+	return cart.Quantities(order.Order_Cart)
+}
+func Key(line models.ShipmentLine) cart.LineKey {
+	return cart.LineKey{Product_ID: line.Product_ID, SKU: line.Variant_SKU}
+}
+func quantities(shipments []models.Shipment, statuses ...string) map[cart.LineKey]int {
+	counted := make(map[cart.LineKey]int)
+	for _, shipment := range shipments {
+		match := len(statuses) == 0 && shipment.Status != models.ShipmentCancelled
+		for _, status := range statuses {
+			match = match || shipment.Status == status
+		}
+		if !match {
+			continue
+		}
+		for _, line := range shipment.Lines {
+			counted[Key(line)] += line.Quantity
+		}
+	}
+	return counted
+}
+func Remaining(order models.Order, shipments []models.Shipment) map[cart.LineKey]int {
+	remaining := Ordered(order)
+	for key, quantity := range quantities(shipments) {
+		remaining[key] -= quantity
+	}
+	for key, quantity := range remaining {
+		if quantity <= 0 {
+			delete(remaining, key)
+		}
+	}
+	return remaining
+}
+func CheckLines(order models.Order, shipments []models.Shipment, lines []models.ShipmentLine) error {
+	if len(lines) == 0 {
+		return ErrEmptyShipment
+	}
+	remaining := Remaining(order, shipments)
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return ErrEmptyShipment
+		}
+		if line.Quantity > remaining[Key(line)] {
+			return ErrExceedsOrdered
+		}
+		remaining[Key(line)] -= line.Quantity
+	}
+	return nil
+}
+func covers(ordered, fulfilled map[cart.LineKey]int) bool {
+	for key, quantity := range ordered {
+		if fulfilled[key] < quantity {
+			return false
+		}
+	}
+	return true
+}
+func OrderStatus(order models.Order, shipments []models.Shipment) string {
+	ordered := Ordered(order)
+	shipped := quantities(shipments, models.ShipmentShipped, models.ShipmentInTransit, models.ShipmentDelivered)
+	switch {
+	case covers(ordered, quantities(shipments, models.ShipmentDelivered)):
+		return models.OrderDelivered
+	case covers(ordered, shipped):
+		return models.OrderShipped
+	case len(shipped) > 0:
+		return models.OrderPartiallyShipped
+	}
+	return models.OrderUnfulfilled
+}
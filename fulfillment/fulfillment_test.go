@@ -0,0 +1,61 @@
+package fulfillment
+import (
+	"testing"
+	"ecommerce/cart"
+	"ecommerce/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func order(ids ...primitive.ObjectID) models.Order {
+	lines := make([]models.ProductUser, 0, len(ids))
+	for _, id := range ids {
+		lines = append(lines, models.ProductUser{Product_ID: id})
+	}
+	return models.Order{Order_ID: primitive.NewObjectID(), Order_Cart: lines}
+}
+func shipment(status string, lines ...models.ShipmentLine) models.Shipment {
+	return models.Shipment{Status: status, Lines: lines}
+}
+func TestCheckLinesAllowsPartialFulfillment(t *testing.T) {
+	shirt := primitive.NewObjectID()
+	mug := primitive.NewObjectID()
+	o := order(shirt, shirt, mug)
+	shipped := []models.Shipment{shipment(models.ShipmentShipped, models.ShipmentLine{Product_ID: shirt, Quantity: 1})}
+	assert.NoError(t, CheckLines(o, shipped, []models.ShipmentLine{{Product_ID: shirt, Quantity: 1}, {Product_ID: mug, Quantity: 1}}))
+	assert.ErrorIs(t, CheckLines(o, shipped, []models.ShipmentLine{{Product_ID: shirt, Quantity: 2}}), ErrExceedsOrdered)
+	assert.ErrorIs(t, CheckLines(o, shipped, []models.ShipmentLine{{Product_ID: shirt, Quantity: 1}, {Product_ID: shirt, Quantity: 1}}), ErrExceedsOrdered)
+	assert.ErrorIs(t, CheckLines(o, shipped, []models.ShipmentLine{{Product_ID: primitive.NewObjectID(), Quantity: 1}}), ErrExceedsOrdered)
+	assert.ErrorIs(t, CheckLines(o, shipped, nil), ErrEmptyShipment)
+	cancelled := append(shipped, shipment(models.ShipmentCancelled, models.ShipmentLine{Product_ID: mug, Quantity: 1}))
+	assert.Equal(t, map[cart.LineKey]int{{Product_ID: shirt}: 1, {Product_ID: mug}: 1}, Remaining(o, cancelled))
+}
+func TestCheckLinesKeepsVariantsApart(t *testing.T) {
+	shirt := primitive.NewObjectID()
+	o := models.Order{Order_Cart: []models.ProductUser{{Product_ID: shirt, Variant_SKU: "SHIRT-S"}, {Product_ID: shirt, Variant_SKU: "SHIRT-L"}}}
+	assert.NoError(t, CheckLines(o, nil, []models.ShipmentLine{{Product_ID: shirt, Variant_SKU: "SHIRT-S", Quantity: 1}, {Product_ID: shirt, Variant_SKU: "SHIRT-L", Quantity: 1}}))
+	assert.ErrorIs(t, CheckLines(o, nil, []models.ShipmentLine{{Product_ID: shirt, Variant_SKU: "SHIRT-S", Quantity: 2}}), ErrExceedsOrdered)
+	assert.ErrorIs(t, CheckLines(o, nil, []models.ShipmentLine{{Product_ID: shirt, Quantity: 1}}), ErrExceedsOrdered)
+	shipped := []models.Shipment{shipment(models.ShipmentShipped, models.ShipmentLine{Product_ID: shirt, Variant_SKU: "SHIRT-S", Quantity: 1})}
+	assert.Equal(t, models.OrderPartiallyShipped, OrderStatus(o, shipped))
+}
+func TestOrderStatus(t *testing.T) {
+	shirt := primitive.NewObjectID()
+	mug := primitive.NewObjectID()
+	o := order(shirt, shirt, mug)
+	assert.Equal(t, models.OrderUnfulfilled, OrderStatus(o, nil))
+	pending := []models.Shipment{shipment(models.ShipmentPending, models.ShipmentLine{Product_ID: shirt, Quantity: 2}, models.ShipmentLine{Product_ID: mug, Quantity: 1})}
+	assert.Equal(t, models.OrderUnfulfilled, OrderStatus(o, pending))
+	partial := []models.Shipment{shipment(models.ShipmentShipped, models.ShipmentLine{Product_ID: shirt, Quantity: 2})}
+	assert.Equal(t, models.OrderPartiallyShipped, OrderStatus(o, partial))
+	complete := append(partial, shipment(models.ShipmentDelivered, models.ShipmentLine{Product_ID: mug, Quantity: 1}))
+	assert.Equal(t, models.OrderShipped, OrderStatus(o, complete))
+	complete[0].Status = models.ShipmentDelivered
+	assert.Equal(t, models.OrderDelivered, OrderStatus(o, complete))
+}
+func TestCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(models.ShipmentPending, models.ShipmentShipped))
+	assert.True(t, CanTransition(models.ShipmentShipped, models.ShipmentDelivered))
+	assert.False(t, CanTransition(models.ShipmentDelivered, models.ShipmentShipped))
+	assert.False(t, CanTransition(models.ShipmentShipped, models.ShipmentCancelled))
+	assert.False(t, CanTransition(models.ShipmentCancelled, models.ShipmentPending))
+}
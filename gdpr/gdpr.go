@@ -0,0 +1,223 @@
+package gdpr
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"time"
+	"ecommerce/audit"
+	"ecommerce/config"
+	"ecommerce/database"
+	"ecommerce/loginguard"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+const (
+	EventExport            = "gdpr.export"
+	EventDeletionRequested = "gdpr.deletion_requested"
+	EventDeletionCancelled = "gdpr.deletion_cancelled"
+	EventAnonymized        = "gdpr.anonymized"
+)
+var (
+	UserCollection         *mongo.Collection = database.UserData(database.Client, "Users")
+	SessionCollection      *mongo.Collection = database.UserData(database.Client, "Sessions")
+	APIKeyCollection       *mongo.Collection = database.UserData(database.Client, "APIKeys")
+	UserTokenCollection    *mongo.Collection = database.UserData(database.Client, "UserTokens")
+	InvoiceCollection      *mongo.Collection = database.UserData(database.Client, "Invoices")
+	ShipmentCollection     *mongo.Collection = database.UserData(database.Client, "Shipments")
+	RedemptionCollection   *mongo.Collection = database.UserData(database.Client, "CouponRedemptions")
+	GuestCartCollection    *mongo.Collection = database.UserData(database.Client, "GuestCarts")
+	LoginAttemptCollection *mongo.Collection = database.UserData(database.Client, "LoginAttempts")
+)
+func GracePeriod() time.Duration {
+	return config.Duration("GDPR_DELETION_GRACE", 30*24*time.Hour)
+}
+func findAll(ctx context.Context, collection *mongo.Collection, filter bson.M, sort string, results interface{}) error {
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{sort: 1}))
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+func Export(ctx context.Context, userID string, guestCartIDs ...primitive.ObjectID) (models.DataExport, error) {
+	export := models.DataExport{
+		Generated_At:       time.Now(),
+		Sessions:           make([]models.Session, 0),
+		API_Keys:           make([]models.APIKey, 0),
+		Audit_Events:       make([]models.AuditEvent, 0),
+		Invoices:           make([]models.Invoice, 0),
+		Shipments:          make([]models.Shipment, 0),
+		Coupon_Redemptions: make([]models.CouponRedemption, 0),
+		Guest_Carts:        make([]models.GuestCart, 0),
+	}
+	var founduser models.User
+	if err := UserCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&founduser); err != nil {
+		return export, err
+	}
+	export.Profile = models.NewUserResponse(founduser)
+	if err := findAll(ctx, SessionCollection, bson.M{"user_id": userID}, "created_at", &export.Sessions); err != nil {
+		return export, err
+	}
+	if err := findAll(ctx, APIKeyCollection, bson.M{"user_id": userID}, "created_at", &export.API_Keys); err != nil {
+		return export, err
+	}
+	if err := findAll(ctx, audit.AuditCollection, bson.M{"user_id": userID}, "created_at", &export.Audit_Events); err != nil {
+		return export, err
+	}
+	if err := findAll(ctx, InvoiceCollection, bson.M{"user_id": userID}, "issued_at", &export.Invoices); err != nil {
+		return export, err
+	}
+	if err := findAll(ctx, ShipmentCollection, bson.M{"user_id": userID}, "created_at", &export.Shipments); err != nil {
+		return export, err
+	}
+	if err := findAll(ctx, RedemptionCollection, bson.M{"user_id": userID}, "created_at", &export.Coupon_Redemptions); err != nil {
+		return export, err
+	}
+	if len(guestCartIDs) > 0 {
+		if err := findAll(ctx, GuestCartCollection, bson.M{"_id": bson.M{"$in": guestCartIDs}}, "created_at", &export.Guest_Carts); err != nil {
+			return export, err
+		}
+	}
+	return export, nil
+}
+func WriteZip(w io.Writer, export models.DataExport) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"cart.json", export.Profile.UserCart},
+		{"wishlist.json", export.Profile.Wishlist},
+		{"addresses.json", export.Profile.Address_Details},
+		{"orders.json", export.Profile.Order_Status},
+		{"sessions.json", export.Sessions},
+		{"api_keys.json", export.API_Keys},
+		{"audit_events.json", export.Audit_Events},
+		{"invoices.json", export.Invoices},
+		{"shipments.json", export.Shipments},
+		{"coupon_redemptions.json", export.Coupon_Redemptions},
+		{"guest_carts.json", export.Guest_Carts},
+	}
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.Generated_At})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+func RequestDeletion(ctx context.Context, userID string) (time.Time, error) {
+	now := time.Now()
+	due := now.Add(GracePeriod())
+	update := bson.M{"$set": bson.M{"delete_requested": now, "delete_after": due, "updated_at": now}}
+	_, err := UserCollection.UpdateOne(ctx, bson.M{"user_id": userID, "anonymized_at": nil}, update)
+	if err != nil {
+		return due, err
+	}
+	audit.Record(ctx, models.AuditEvent{Type: EventDeletionRequested, User_ID: userID, Actor: "user:" + userID, Details: map[string]interface{}{"delete_after": due}})
+	return due, nil
+}
+func CancelDeletion(ctx context.Context, userID string) (bool, error) {
+	filter := bson.M{"user_id": userID, "anonymized_at": nil, "delete_after": bson.M{"$ne": nil}}
+	update := bson.M{"$unset": bson.M{"delete_requested": "", "delete_after": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	result, err := UserCollection.UpdateOne(ctx, filter, update)
+	if err != nil || result.ModifiedCount == 0 {
+		return false, err
+	}
+	audit.Record(ctx, models.AuditEvent{Type: EventDeletionCancelled, User_ID: userID, Actor: "user:" + userID})
+	return true, nil
+}
+func Anonymize(ctx context.Context, userID string) error {
+	var founduser models.User
+	if err := UserCollection.FindOne(ctx, bson.M{"user_id": userID}, options.FindOne().SetProjection(bson.M{"email": 1})).Decode(&founduser); err != nil {
+		return err
+	}
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"first_name":    "Deleted",
+			"last_name":     "User",
+			"email":         "deleted-" + userID + "@invalid.invalid",
+			"usercart":      make([]models.ProductUser, 0),
+			"wishlist":      make([]models.WishlistItem, 0),
+			"address":       make([]models.Address, 0),
+			"totp_enabled":  false,
+			"anonymized_at": now,
+			"updated_at":    now,
+		},
+		"$unset": bson.M{
+			"phone":            "",
+			"password":         "",
+			"token":            "",
+			"refresh_token":    "",
+			"verified_at":      "",
+			"totp_secret":      "",
+			"totp_pending":     "",
+			"recovery_codes":   "",
+			"delete_requested": "",
+			"delete_after":     "",
+		},
+	}
+	if _, err := UserCollection.UpdateOne(ctx, bson.M{"user_id": userID}, update); err != nil {
+		return err
+	}
+	for _, collection := range []*mongo.Collection{SessionCollection, APIKeyCollection, UserTokenCollection} {
+		if _, err := collection.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+			return err
+		}
+	}
+	keys := []string{loginguard.UserKey(userID)}
+	if founduser.Email != nil {
+		keys = append(keys, loginguard.EmailKey(strings.ToLower(strings.TrimSpace(*founduser.Email))))
+	}
+	if _, err := LoginAttemptCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": keys}}); err != nil {
+		return err
+	}
+	audit.Record(ctx, models.AuditEvent{Type: EventAnonymized, User_ID: userID, Actor: "system", Details: map[string]interface{}{"orders_retained": true}})
+	return nil
+}
+func ProcessDueDeletions(ctx context.Context) (int, error) {
+	var due []models.User
+	filter := bson.M{"anonymized_at": nil, "delete_after": bson.M{"$lte": time.Now()}}
+	cursor, err := UserCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"user_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	if err = cursor.All(ctx, &due); err != nil {
+		return 0, err
+	}
+	processed := 0
+	for _, user := range due {
+		if err := Anonymize(ctx, user.User_ID); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+func RunWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		processed, err := ProcessDueDeletions(ctx)
+		cancel()
+		if err != nil {
+			log.Println("gdpr: ", err)
+		}
+		if processed > 0 {
+			log.Printf("gdpr: anonymized %d accounts", processed)
+		}
+	}
+}
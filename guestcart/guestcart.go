@@ -0,0 +1,77 @@
+package guestcart
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"ecommerce/cart"
+	"ecommerce/config"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+const (
+	Header      = "X-Cart-Token"
+	MergeSum    = "sum"
+	MergeNewest = "newest"
+)
+var (
+	ErrInvalidToken  = errors.New("invalid cart token")
+	ErrMissingSecret = errors.New("CART_TOKEN_SECRET must be set")
+)
+type Signer struct {
+	secret []byte
+}
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret: secret}
+}
+func FromEnv() (*Signer, error) {
+	secret := config.String("CART_TOKEN_SECRET", "")
+	if secret == "" {
+		return nil, ErrMissingSecret
+	}
+	return NewSigner([]byte(secret)), nil
+}
+func (s *Signer) mac(payload string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+func (s *Signer) Sign(cartID primitive.ObjectID) string {
+	payload := cartID.Hex()
+	return payload + "." + s.mac(payload)
+}
+func (s *Signer) Verify(token string) (primitive.ObjectID, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(s.mac(payload))) {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	cartID, err := primitive.ObjectIDFromHex(payload)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	return cartID, nil
+}
+func Strategy() string {
+	if config.String("CART_MERGE_STRATEGY", MergeSum) == MergeNewest {
+		return MergeNewest
+	}
+	return MergeSum
+}
+func Merge(usercart, guestcart []models.ProductUser, strategy string) []models.ProductUser {
+	merged := make([]models.ProductUser, 0, len(usercart)+len(guestcart))
+	if strategy != MergeNewest {
+		merged = append(merged, usercart...)
+		return append(merged, guestcart...)
+	}
+	inGuest := make(map[cart.LineKey]bool, len(guestcart))
+	for _, line := range guestcart {
+		inGuest[cart.Key(line)] = true
+	}
+	for _, line := range usercart {
+		if !inGuest[cart.Key(line)] {
+			merged = append(merged, line)
+		}
+	}
+	return append(merged, guestcart...)
+}
@@ -0,0 +1,47 @@
+package guestcart
+import (
+	"testing"
+	"ecommerce/models"
+	"ecommerce/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func TestSignAndVerify(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	cartID := primitive.NewObjectID()
+	token := signer.Sign(cartID)
+	verified, err := signer.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, cartID, verified)
+	_, err = NewSigner([]byte("other")).Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = signer.Verify(primitive.NewObjectID().Hex() + token[24:])
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = signer.Verify(cartID.Hex())
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+func TestMerge(t *testing.T) {
+	shoes := models.ProductUser{Product_ID: primitive.NewObjectID(), Price: money.New(100, "")}
+	hat := models.ProductUser{Product_ID: primitive.NewObjectID(), Price: money.New(20, "")}
+	newShoes := models.ProductUser{Product_ID: shoes.Product_ID, Price: money.New(90, "")}
+	usercart := []models.ProductUser{shoes, shoes, hat}
+	guestcart := []models.ProductUser{newShoes}
+	summed := Merge(usercart, guestcart, MergeSum)
+	assert.Len(t, summed, 4)
+	newest := Merge(usercart, guestcart, MergeNewest)
+	assert.Equal(t, []models.ProductUser{hat, newShoes}, newest)
+	assert.Empty(t, Merge(nil, nil, MergeSum))
+	small := models.ProductUser{Product_ID: shoes.Product_ID, Variant_SKU: "SHOE-40", Price: money.New(100, "")}
+	large := models.ProductUser{Product_ID: shoes.Product_ID, Variant_SKU: "SHOE-44", Price: money.New(100, "")}
+	newLarge := models.ProductUser{Product_ID: shoes.Product_ID, Variant_SKU: "SHOE-44", Price: money.New(90, "")}
+	newest = Merge([]models.ProductUser{small, large}, []models.ProductUser{newLarge}, MergeNewest)
+	assert.Equal(t, []models.ProductUser{small, newLarge}, newest)
+}
+func TestStrategy(t *testing.T) {
+	assert.Equal(t, MergeSum, Strategy())
+	t.Setenv("CART_MERGE_STRATEGY", "newest")
+	assert.Equal(t, MergeNewest, Strategy())
+	t.Setenv("CART_MERGE_STRATEGY", "bogus")
+	assert.Equal(t, MergeSum, Strategy())
+}
@@ -0,0 +1,303 @@
+package invoicing
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"ecommerce/config"
+	"ecommerce/models"
+	"ecommerce/money"
+	"ecommerce/pdf"
+)
+var (
+	ErrNothingToCredit = errors.New("invoice has already been fully credited")
+	ErrCreditTooLarge  = errors.New("credit amount exceeds the outstanding invoice total")
+	ErrInvalidCredit   = errors.New("credit amount must be positive and in the invoice currency")
+)
+var prefixes = map[string]string{
+	models.InvoiceKindInvoice:    "INV",
+	models.InvoiceKindCreditNote: "CN",
+}
+func Number(kind string, year int, sequence int64) string {
+	return fmt.Sprintf("%s-%d-%06d", prefixes[kind], year, sequence)
+}
+func PaymentMethod(payment models.Payment) string {
+	switch {
+	case payment.Digital:
+		return "Digital payment"
+	case payment.COD:
+		return "Cash on delivery"
+	}
+	return "Unspecified"
+}
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+func lines(order models.Order) ([]models.InvoiceLine, error) {
+	result := make([]models.InvoiceLine, 0)
+	index := make(map[string]int)
+	for _, item := range order.Order_Cart {
+		key := item.Product_ID.Hex() + "|" + item.Variant_SKU + "|" + item.Price.String()
+		if i, ok := index[key]; ok {
+			total, err := result[i].Total.Add(item.Price)
+			if err != nil {
+				return nil, err
+			}
+			result[i].Quantity++
+			result[i].Total = total
+			continue
+		}
+		index[key] = len(result)
+		description := deref(item.Product_Name)
+		if description == "" {
+			description = item.Product_ID.Hex()
+		}
+		if item.Variant_SKU != "" {
+			description += " (" + item.Variant_SKU + ")"
+		}
+		result = append(result, models.InvoiceLine{Product_ID: item.Product_ID, SKU: item.Variant_SKU, Description: description, Quantity: 1, Unit_Price: item.Price, Total: item.Price})
+	}
+	return result, nil
+}
+func FromOrder(user models.User, order models.Order) (models.Invoice, error) {
+	items, err := lines(order)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	invoice := models.Invoice{
+		Kind:             models.InvoiceKindInvoice,
+		Order_ID:         order.Order_ID,
+		User_ID:          user.ID.Hex(),
+		Customer_Name:    strings.TrimSpace(deref(user.First_Name) + " " + deref(user.Last_Name)),
+		Customer_Email:   deref(user.Email),
+		Address:          order.Shipping_Address,
+		Currency:         order.Currency,
+		Lines:            items,
+		Subtotal:         order.Subtotal,
+		Discounts:        order.Discounts,
+		Shipping_Method:  order.Shipping_Method,
+		Shipping:         order.Shipping_Cost,
+		Tax:              order.Tax,
+		Tax_Jurisdiction: order.Tax_Jurisdiction,
+		Total:            order.Price,
+		Payment_Method:   PaymentMethod(order.Payment_Method),
+	}
+	if invoice.Currency == "" {
+		invoice.Currency = order.Price.Currency
+	}
+	if invoice.Address == nil && len(user.Address_Details) > 0 {
+		invoice.Address = &user.Address_Details[0]
+	}
+	for _, line := range order.Tax_Lines {
+		invoice.Tax_Inclusive = invoice.Tax_Inclusive || (line.Inclusive && !line.Tax.IsZero())
+	}
+	return invoice, nil
+}
+func Outstanding(invoice models.Invoice, credits []models.Invoice) (money.Money, error) {
+	outstanding := invoice.Total
+	for _, credit := range credits {
+		var err error
+		if outstanding, err = outstanding.Sub(credit.Total); err != nil {
+			return outstanding, err
+		}
+	}
+	return outstanding, nil
+}
+func CreditNote(invoice models.Invoice, credits []models.Invoice, amount *money.Money, reason string) (models.Invoice, error) {
+	outstanding, err := Outstanding(invoice, credits)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	if outstanding.Amount <= 0 {
+		return models.Invoice{}, ErrNothingToCredit
+	}
+	credited := outstanding
+	if amount != nil {
+		if amount.Amount <= 0 || amount.Currency != invoice.Total.Currency {
+			return models.Invoice{}, ErrInvalidCredit
+		}
+		if outstanding.LessThan(*amount) {
+			return models.Invoice{}, ErrCreditTooLarge
+		}
+		credited = *amount
+	}
+	tax := money.Zero(invoice.Tax.Currency)
+	if !invoice.Total.IsZero() {
+		if tax, err = invoice.Tax.MulRat(credited.Amount, invoice.Total.Amount, money.RoundHalfEven); err != nil {
+			return models.Invoice{}, err
+		}
+	}
+	note := models.Invoice{
+		Kind:             models.InvoiceKindCreditNote,
+		Order_ID:         invoice.Order_ID,
+		User_ID:          invoice.User_ID,
+		Customer_Name:    invoice.Customer_Name,
+		Customer_Email:   invoice.Customer_Email,
+		Address:          invoice.Address,
+		Currency:         invoice.Currency,
+		Lines:            []models.InvoiceLine{{Description: "Refund for invoice " + invoice.Number, Quantity: 1, Unit_Price: credited, Total: credited}},
+		Subtotal:         credited,
+		Shipping:         money.Zero(credited.Currency),
+		Tax:              tax,
+		Tax_Inclusive:    true,
+		Tax_Jurisdiction: invoice.Tax_Jurisdiction,
+		Total:            credited,
+		Payment_Method:   invoice.Payment_Method,
+		Credits_Invoice:  invoice.Number,
+		Reason:           reason,
+	}
+	return note, nil
+}
+type Seller struct {
+	Name    string
+	Address []string
+	Tax_ID  string
+}
+func DefaultSeller() Seller {
+	seller := Seller{Name: config.String("INVOICE_SELLER_NAME", "Ecommerce"), Tax_ID: config.String("INVOICE_SELLER_TAX_ID", "")}
+	for _, line := range strings.Split(config.String("INVOICE_SELLER_ADDRESS", ""), "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			seller.Address = append(seller.Address, line)
+		}
+	}
+	return seller
+}
+func addressLines(address *models.Address) []string {
+	if address == nil {
+		return nil
+	}
+	lines := make([]string, 0, 4)
+	for _, line := range []string{
+		strings.TrimSpace(deref(address.House) + " " + deref(address.Street)),
+		strings.TrimSpace(deref(address.City) + " " + deref(address.Pincode)),
+		strings.TrimSpace(deref(address.Region) + " " + deref(address.Country)),
+	} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+const (
+	margin     = 50.0
+	right      = pdf.PageWidth - margin
+	bottom     = 90.0
+	lineHeight = 15.0
+)
+type renderer struct {
+	doc  *pdf.Document
+	page *pdf.Page
+	y    float64
+}
+func (r *renderer) newPage() {
+	r.page = r.doc.AddPage()
+	r.y = pdf.PageHeight - margin
+}
+func (r *renderer) tableHeader() {
+	r.page.Rect(margin, r.y-4, right-margin, lineHeight+2, 0.9)
+	r.page.Text(margin+4, r.y, 9, true, "Description")
+	r.page.TextRight(right-190, r.y, 9, true, "Qty")
+	r.page.TextRight(right-95, r.y, 9, true, "Unit price")
+	r.page.TextRight(right-4, r.y, 9, true, "Amount")
+	r.y -= lineHeight + 4
+}
+func (r *renderer) ensure(space float64, header bool) {
+	if r.y-space >= bottom {
+		return
+	}
+	r.newPage()
+	if header {
+		r.tableHeader()
+	}
+}
+func (r *renderer) total(label string, amount string, bold bool) {
+	r.ensure(lineHeight, false)
+	r.page.TextRight(right-110, r.y, 10, bold, label)
+	r.page.TextRight(right-4, r.y, 10, bold, amount)
+	r.y -= lineHeight
+}
+func Render(invoice models.Invoice, seller Seller) ([]byte, error) {
+	title := "INVOICE"
+	if invoice.Kind == models.InvoiceKindCreditNote {
+		title = "CREDIT NOTE"
+	}
+	r := &renderer{doc: pdf.New(title + " " + invoice.Number)}
+	r.newPage()
+	r.page.Text(margin, r.y, 20, true, title)
+	r.page.TextRight(right, r.y, 12, true, seller.Name)
+	sellerY := r.y - lineHeight
+	for _, line := range append(append([]string{}, seller.Address...), taxIDLine(seller.Tax_ID)...) {
+		r.page.TextRight(right, sellerY, 9, false, line)
+		sellerY -= 12
+	}
+	r.y -= 30
+	details := [][2]string{
+		{"Number", invoice.Number},
+		{"Date", invoice.Issued_At.Format("2006-01-02")},
+		{"Order", invoice.Order_ID.Hex()},
+		{"Payment", invoice.Payment_Method},
+	}
+	if invoice.Credits_Invoice != "" {
+		details = append(details, [2]string{"Credits invoice", invoice.Credits_Invoice})
+	}
+	for _, detail := range details {
+		r.page.Text(margin, r.y, 10, true, detail[0]+":")
+		r.page.Text(margin+90, r.y, 10, false, detail[1])
+		r.y -= lineHeight
+	}
+	if r.y > sellerY {
+		r.y = sellerY
+	}
+	r.y -= 10
+	r.page.Text(margin, r.y, 10, true, "Bill to / Ship to")
+	r.y -= lineHeight
+	for _, line := range append([]string{invoice.Customer_Name, invoice.Customer_Email}, addressLines(invoice.Address)...) {
+		if line == "" {
+			continue
+		}
+		r.page.Text(margin, r.y, 10, false, line)
+		r.y -= 13
+	}
+	r.y -= 15
+	r.tableHeader()
+	for _, line := range invoice.Lines {
+		r.ensure(lineHeight, true)
+		r.page.Text(margin+4, r.y, 9, false, line.Description)
+		r.page.TextRight(right-190, r.y, 9, false, fmt.Sprint(line.Quantity))
+		r.page.TextRight(right-95, r.y, 9, false, line.Unit_Price.String())
+		r.page.TextRight(right-4, r.y, 9, false, line.Total.String())
+		r.y -= lineHeight
+	}
+	r.page.Line(margin, r.y+8, right, r.y+8, 0.5)
+	r.y -= 6
+	r.total("Subtotal", invoice.Subtotal.String(), false)
+	for _, discount := range invoice.Discounts {
+		r.total(discount.Description, "-"+discount.Amount.String(), false)
+	}
+	if invoice.Shipping_Method != "" || !invoice.Shipping.IsZero() {
+		r.total(strings.TrimSpace("Shipping "+invoice.Shipping_Method), invoice.Shipping.String(), false)
+	}
+	taxLabel := "Tax"
+	if invoice.Tax_Jurisdiction != "" {
+		taxLabel += " (" + invoice.Tax_Jurisdiction + ")"
+	}
+	if invoice.Tax_Inclusive {
+		taxLabel += " included"
+	}
+	r.total(taxLabel, invoice.Tax.String(), false)
+	r.total("Total", invoice.Total.String(), true)
+	if invoice.Reason != "" {
+		r.y -= 10
+		r.ensure(lineHeight, false)
+		r.page.Text(margin, r.y, 10, false, "Reason: "+invoice.Reason)
+	}
+	return r.doc.Bytes()
+}
+func taxIDLine(taxID string) []string {
+	if taxID == "" {
+		return nil
+	}
+	return []string{"Tax ID: " + taxID}
+}
@@ -0,0 +1,112 @@
+package invoicing
+import (
+	"bytes"
+	"testing"
+	"time"
+	"ecommerce/models"
+	"ecommerce/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func usd(amount int64) money.Money {
+	return money.New(amount, "USD")
+}
+func sampleOrder() (models.User, models.Order) {
+	first, last, email := "Ada", "Lovelace", "ada@example.com"
+	shirtName := "T-Shirt (blue)"
+	shirt := primitive.NewObjectID()
+	mug := primitive.NewObjectID()
+	user := models.User{ID: primitive.NewObjectID(), First_Name: &first, Last_Name: &last, Email: &email}
+	order := models.Order{
+		Order_ID: primitive.NewObjectID(),
+		Order_Cart: []models.ProductUser{
+			{Product_ID: shirt, Product_Name: &shirtName, Price: usd(1500)},
+			{Product_ID: mug, Price: usd(800)},
+			{Product_ID: shirt, Product_Name: &shirtName, Price: usd(1500)},
+		},
+		Subtotal:        usd(3800),
+		Discounts:       []models.DiscountLine{{Description: "Spring sale", Amount: usd(300)}},
+		Shipping_Method: "standard",
+		Shipping_Cost:   usd(500),
+		Tax:             usd(350),
+		Tax_Lines:       []models.TaxLine{{Tax: usd(350)}},
+		Price:           usd(4350),
+		Currency:        "USD",
+	}
+	order.Payment_Method.COD = true
+	return user, order
+}
+func TestFromOrderGroupsLines(t *testing.T) {
+	user, order := sampleOrder()
+	invoice, err := FromOrder(user, order)
+	require.NoError(t, err)
+	require.Len(t, invoice.Lines, 2)
+	assert.Equal(t, 2, invoice.Lines[0].Quantity)
+	assert.Equal(t, usd(3000), invoice.Lines[0].Total)
+	assert.Equal(t, order.Order_Cart[1].Product_ID.Hex(), invoice.Lines[1].Description)
+	assert.Equal(t, "Ada Lovelace", invoice.Customer_Name)
+	assert.Equal(t, "Cash on delivery", invoice.Payment_Method)
+	assert.False(t, invoice.Tax_Inclusive)
+	assert.Equal(t, usd(4350), invoice.Total)
+}
+func TestFromOrderSeparatesVariants(t *testing.T) {
+	user, order := sampleOrder()
+	name := "Tee"
+	small := models.ProductUser{Product_ID: order.Order_Cart[0].Product_ID, Product_Name: &name, Price: usd(1500), Variant_SKU: "TEE-S"}
+	large := small
+	large.Variant_SKU = "TEE-L"
+	order.Order_Cart = []models.ProductUser{small, large, large}
+	invoice, err := FromOrder(user, order)
+	require.NoError(t, err)
+	require.Len(t, invoice.Lines, 2)
+	assert.Equal(t, "Tee (TEE-S)", invoice.Lines[0].Description)
+	assert.Equal(t, "TEE-L", invoice.Lines[1].SKU)
+	assert.Equal(t, 2, invoice.Lines[1].Quantity)
+}
+func TestNumber(t *testing.T) {
+	assert.Equal(t, "INV-2026-000042", Number(models.InvoiceKindInvoice, 2026, 42))
+	assert.Equal(t, "CN-2027-000001", Number(models.InvoiceKindCreditNote, 2027, 1))
+}
+func TestCreditNoteLimits(t *testing.T) {
+	user, order := sampleOrder()
+	invoice, err := FromOrder(user, order)
+	require.NoError(t, err)
+	invoice.Number = "INV-2026-000001"
+	partial := usd(1000)
+	note, err := CreditNote(invoice, nil, &partial, "damaged mug")
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoiceKindCreditNote, note.Kind)
+	assert.Equal(t, "INV-2026-000001", note.Credits_Invoice)
+	assert.Equal(t, usd(1000), note.Total)
+	assert.Equal(t, usd(80), note.Tax)
+	tooMuch := usd(3400)
+	_, err = CreditNote(invoice, []models.Invoice{note}, &tooMuch, "")
+	assert.ErrorIs(t, err, ErrCreditTooLarge)
+	euros := money.New(100, "EUR")
+	_, err = CreditNote(invoice, []models.Invoice{note}, &euros, "")
+	assert.ErrorIs(t, err, ErrInvalidCredit)
+	rest, err := CreditNote(invoice, []models.Invoice{note}, nil, "order cancelled")
+	require.NoError(t, err)
+	assert.Equal(t, usd(3350), rest.Total)
+	_, err = CreditNote(invoice, []models.Invoice{note, rest}, nil, "")
+	assert.ErrorIs(t, err, ErrNothingToCredit)
+}
+func TestRenderProducesPDF(t *testing.T) {
+	user, order := sampleOrder()
+	invoice, err := FromOrder(user, order)
+	require.NoError(t, err)
+	invoice.Number = "INV-2026-000007"
+	invoice.Issued_At = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 80; i++ {
+		invoice.Lines = append(invoice.Lines, invoice.Lines[0])
+	}
+	out, err := Render(invoice, Seller{Name: "Shop", Address: []string{"1 Main St"}, Tax_ID: "US123"})
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-")))
+	assert.Contains(t, string(out), "(INV-2026-000007) Tj")
+	assert.Contains(t, string(out), `(T-Shirt \(blue\)) Tj`)
+	assert.Contains(t, string(out), "(-3.00 USD) Tj")
+	assert.Contains(t, string(out), "(43.50 USD) Tj")
+	assert.Contains(t, string(out), "/Count 3")
+}
@@ -0,0 +1,102 @@
+package loginguard
+import (
+	"context"
+	"time"
+	"ecommerce/config"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+type Guard struct {
+	collection    *mongo.Collection
+	MaxAttempts   int
+	IPMaxAttempts int
+	BaseLockout   time.Duration
+	MaxLockout    time.Duration
+	Window        time.Duration
+}
+func New(collection *mongo.Collection) *Guard {
+	g := &Guard{
+		collection:    collection,
+		MaxAttempts:   config.Int("LOGIN_MAX_ATTEMPTS", 5),
+		IPMaxAttempts: config.Int("LOGIN_IP_MAX_ATTEMPTS", 20),
+		BaseLockout:   config.Duration("LOGIN_LOCKOUT_BASE", time.Minute),
+		MaxLockout:    config.Duration("LOGIN_LOCKOUT_MAX", time.Hour),
+		Window:        config.Duration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+	}
+	if g.Window < g.MaxLockout {
+		g.Window = g.MaxLockout
+	}
+	return g
+}
+func EmailKey(email string) string {
+	return "email:" + email
+}
+func UserKey(userID string) string {
+	return "user:" + userID
+}
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+func Backoff(failures int, threshold int, base time.Duration, max time.Duration) time.Duration {
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+	d := base
+	for i := threshold; i < failures; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+func (g *Guard) Locked(ctx context.Context, keys ...string) (time.Duration, error) {
+	var attempts []models.LoginAttempt
+	cursor, err := g.collection.Find(ctx, bson.M{"_id": bson.M{"$in": keys}, "locked_until": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	if err = cursor.All(ctx, &attempts); err != nil {
+		return 0, err
+	}
+	var remaining time.Duration
+	for _, attempt := range attempts {
+		if wait := time.Until(*attempt.Locked_Until); wait > remaining {
+			remaining = wait
+		}
+	}
+	return remaining, nil
+}
+func (g *Guard) Fail(ctx context.Context, key string, threshold int) (models.LoginAttempt, time.Duration, error) {
+	now := time.Now()
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"failures": bson.M{"$cond": bson.A{
+			bson.M{"$lt": bson.A{"$last_failure", now.Add(-g.Window)}},
+			1,
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}},
+		}},
+		"last_failure": now,
+	}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var attempt models.LoginAttempt
+	if err := g.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&attempt); err != nil {
+		return attempt, 0, err
+	}
+	lockout := Backoff(attempt.Failures, threshold, g.BaseLockout, g.MaxLockout)
+	if lockout == 0 {
+		return attempt, 0, nil
+	}
+	lockedUntil := now.Add(lockout)
+	attempt.Locked_Until = &lockedUntil
+	_, err := g.collection.UpdateOne(ctx, bson.M{"_id": key}, bson.M{"$set": bson.M{"locked_until": lockedUntil}})
+	return attempt, lockout, err
+}
+func (g *Guard) Reset(ctx context.Context, key string) error {
+	_, err := g.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,30 @@
+package loginguard
+import (
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+func TestBackoff(t *testing.T) {
+	base := time.Minute
+	max := time.Hour
+	assert.Equal(t, time.Duration(0), Backoff(4, 5, base, max))
+	assert.Equal(t, time.Minute, Backoff(5, 5, base, max))
+	assert.Equal(t, 2*time.Minute, Backoff(6, 5, base, max))
+	assert.Equal(t, 4*time.Minute, Backoff(7, 5, base, max))
+	assert.Equal(t, 32*time.Minute, Backoff(10, 5, base, max))
+	assert.Equal(t, time.Hour, Backoff(11, 5, base, max))
+	assert.Equal(t, time.Hour, Backoff(500, 5, base, max))
+	assert.Equal(t, time.Duration(0), Backoff(10, 0, base, max))
+}
+func TestNewKeepsWindowAtLeastMaxLockout(t *testing.T) {
+	t.Setenv("LOGIN_LOCKOUT_MAX", "2h")
+	t.Setenv("LOGIN_ATTEMPT_WINDOW", "15m")
+	g := New(nil)
+	assert.Equal(t, 2*time.Hour, g.Window)
+	assert.Equal(t, 5, g.MaxAttempts)
+}
+func TestKeys(t *testing.T) {
+	assert.Equal(t, "email:test@example.com", EmailKey("test@example.com"))
+	assert.Equal(t, "ip:127.0.0.1", IPKey("127.0.0.1"))
+	assert.Equal(t, "user:123", UserKey("123"))
+}
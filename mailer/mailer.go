@@ -0,0 +1,55 @@
+package mailer
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+type Mailer interface {
+	Send(msg Message) error
+}
+type WriterMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+func NewWriterMailer(w io.Writer) *WriterMailer {
+	return &WriterMailer{w: w}
+}
+func (m *WriterMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := fmt.Fprintf(m.w, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n---\n", time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	return err
+}
+type FileMailer struct {
+	mu   sync.Mutex
+	Path string
+}
+func (m *FileMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return NewWriterMailer(f).Send(msg)
+}
+func FromEnv() Mailer {
+	switch os.Getenv("MAILER") {
+	case "file":
+		path := os.Getenv("MAILER_FILE")
+		if path == "" {
+			path = "mail.log"
+		}
+		return &FileMailer{Path: path}
+	default:
+		return NewWriterMailer(os.Stdout)
+	}
+}
@@ -0,0 +1,37 @@
+package mailer
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+func TestWriterMailer(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewWriterMailer(&buf).Send(Message{To: "test@example.com", Subject: "Hello", Body: "body text"})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "To: test@example.com")
+	assert.Contains(t, buf.String(), "Subject: Hello")
+	assert.Contains(t, buf.String(), "body text")
+}
+func TestFileMailerAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := &FileMailer{Path: path}
+	require.NoError(t, m.Send(Message{To: "a@example.com", Subject: "first"}))
+	require.NoError(t, m.Send(Message{To: "b@example.com", Subject: "second"}))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Subject: first")
+	assert.Contains(t, string(content), "Subject: second")
+}
+func TestFromEnv(t *testing.T) {
+	t.Setenv("MAILER", "file")
+	t.Setenv("MAILER_FILE", "/tmp/out.log")
+	m, ok := FromEnv().(*FileMailer)
+	require.True(t, ok)
+	assert.Equal(t, "/tmp/out.log", m.Path)
+	t.Setenv("MAILER", "")
+	_, ok = FromEnv().(*WriterMailer)
+	assert.True(t, ok)
+}
@@ -1,10 +1,16 @@
 package main
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 	"ecommerce/controllers"
+	"ecommerce/config"
 	"ecommerce/database"
+	"ecommerce/gdpr"
+	"ecommerce/guestcart"
 	"ecommerce/middleware"
+	"ecommerce/models"
 	"ecommerce/routes"
 	"github.com/joho/godotenv"
 	"github.com/gin-gonic/gin"
@@ -19,18 +25,81 @@ func main() {
 		port = "8000"
 	}
 	app := controllers.NewApplication(database.ProductData(database.Client, "Products"), database.UserData(database.Client, "Users"))
+	if controllers.CartTokens, err = guestcart.FromEnv(); err != nil {
+		log.Fatal(err)
+	}
+	go gdpr.RunWorker(config.Duration("GDPR_WORKER_INTERVAL", time.Hour))
+	if err := database.EnsureAPIKeyIndexes(context.Background(), controllers.APIKeyCollection); err != nil {
+		log.Fatal(err)
+	}
+	if err := database.EnsureCouponIndexes(context.Background(), controllers.CouponCollection); err != nil {
+		log.Fatal(err)
+	}
+	if err := database.EnsureInvoiceIndexes(context.Background(), controllers.InvoiceCollection); err != nil {
+		log.Fatal(err)
+	}
+	if err := database.EnsureCategoryIndexes(context.Background(), controllers.CategoryCollection); err != nil {
+		log.Fatal(err)
+	}
+	if err := database.EnsureShipmentIndexes(context.Background(), controllers.ShipmentCollection); err != nil {
+		log.Fatal(err)
+	}
+	if err := database.EnsureShippingMethodIndexes(context.Background(), controllers.ShippingMethodCollection); err != nil {
+		log.Fatal(err)
+	}
+	if err := database.EnsureVariantIndexes(context.Background(), controllers.ProductCollection); err != nil {
+		log.Fatal(err)
+	}
+	if err := database.EnsureGuestCartIndexes(context.Background(), controllers.GuestCartCollection); err != nil {
+		log.Fatal(err)
+	}
 	router := gin.New()
+	if err := router.SetTrustedProxies(config.List("TRUSTED_PROXIES", nil)); err != nil {
+		log.Fatal(err)
+	}
 	router.Use(gin.Logger())
 	routes.UserRoutes(router)
+	router.GET("/guest/cart", controllers.GetGuestCart())
+	router.POST("/guest/cart/items/:id", app.AddToGuestCart())
+	router.DELETE("/guest/cart/items/:id", controllers.RemoveFromGuestCart())
 	router.Use(middleware.Authentication())
-	router.GET("/addtocart", app.AddToCart())
-	router.GET("/removeitem", app.RemoveItem())
-	router.GET("/listcart", controllers.GetItemFromCart())
-	router.POST("/addaddress", controllers.AddAddress())
-	router.PUT("/edithomeaddress", controllers.EditHomeAddress())
-	router.PUT("/editworkaddress", controllers.EditWorkAddress())
-	router.GET("/deleteaddresses", controllers.DeleteAddress())
-	router.GET("/cartcheckout", app.BuyFromCart())
-	router.GET("/instantbuy", app.InstantBuy())
+	router.GET("/addtocart", middleware.RequireCSRF(), middleware.RequireScope(models.ScopeCartWrite), app.AddToCart())
+	router.GET("/removeitem", middleware.RequireCSRF(), middleware.RequireScope(models.ScopeCartWrite), app.RemoveItem())
+	router.GET("/listcart", middleware.RequireScope(models.ScopeCartRead), controllers.GetItemFromCart())
+	router.POST("/addaddress", middleware.RequireScope(models.ScopeAddressWrite), controllers.AddAddress())
+	router.PUT("/edithomeaddress", middleware.RequireScope(models.ScopeAddressWrite), controllers.EditHomeAddress())
+	router.PUT("/editworkaddress", middleware.RequireScope(models.ScopeAddressWrite), controllers.EditWorkAddress())
+	router.GET("/deleteaddresses", middleware.RequireCSRF(), middleware.RequireScope(models.ScopeAddressWrite), controllers.DeleteAddress())
+	router.GET("/cart", middleware.RequireScope(models.ScopeCartRead), app.GetCart())
+	router.GET("/cart/shipping-options", middleware.RequireScope(models.ScopeCartRead), app.ShippingOptions())
+	router.GET("/cart/promotions/explain", middleware.RequireScope(models.ScopeCartRead), app.ExplainPromotions())
+	router.POST("/cart/coupon", middleware.RequireScope(models.ScopeCartWrite), app.ApplyCoupon())
+	router.DELETE("/cart/coupon", middleware.RequireScope(models.ScopeCartWrite), app.RemoveCoupon())
+	router.GET("/cartcheckout", middleware.RequireCSRF(), middleware.RequireScope(models.ScopeOrdersWrite), app.BuyFromCart())
+	router.GET("/instantbuy", middleware.RequireCSRF(), middleware.RequireScope(models.ScopeOrdersWrite), app.InstantBuy())
+	router.GET("/orders/:id/shipments", middleware.RequireScope(models.ScopeOrdersRead), controllers.ListOrderShipments())
+	router.GET("/orders/:id/invoice.pdf", middleware.RequireScope(models.ScopeOrdersRead), controllers.GetInvoicePDF())
+	router.GET("/orders/:id/credit-notes", middleware.RequireScope(models.ScopeOrdersRead), controllers.ListOrderCreditNotes())
+	router.GET("/orders/:id/credit-notes/:number", middleware.RequireScope(models.ScopeOrdersRead), controllers.GetCreditNotePDF())
+	router.GET("/wishlist", middleware.RequireScope(models.ScopeCartRead), app.GetWishlist())
+	router.POST("/wishlist", middleware.RequireScope(models.ScopeCartWrite), app.AddToWishlist())
+	router.DELETE("/wishlist/:id", middleware.RequireScope(models.ScopeCartWrite), app.RemoveFromWishlist())
+	router.POST("/wishlist/:id/move-to-cart", middleware.RequireScope(models.ScopeCartWrite), app.MoveToCart())
+	router.POST("/cart/items/:id/save-for-later", middleware.RequireScope(models.ScopeCartWrite), app.SaveForLater())
+	router.GET("/me", middleware.RequireScope(models.ScopeProfileRead), controllers.GetProfile())
+	router.PATCH("/me", middleware.RequireScope(models.ScopeProfileWrite), controllers.UpdateProfile())
+	router.POST("/me/password", middleware.SessionOnly(), controllers.ChangePassword())
+	router.POST("/me/verify/resend", middleware.SessionOnly(), controllers.ResendVerification())
+	router.POST("/me/2fa/setup", middleware.SessionOnly(), controllers.SetupTwoFactor())
+	router.POST("/me/2fa/confirm", middleware.SessionOnly(), controllers.ConfirmTwoFactor())
+	router.POST("/me/2fa/disable", middleware.SessionOnly(), controllers.DisableTwoFactor())
+	router.POST("/me/api-keys", middleware.SessionOnly(), controllers.CreateAPIKey())
+	router.GET("/me/api-keys", middleware.SessionOnly(), controllers.ListAPIKeys())
+	router.DELETE("/me/api-keys/:id", middleware.SessionOnly(), controllers.DeleteAPIKey())
+	router.GET("/me/sessions", middleware.SessionOnly(), controllers.ListSessions())
+	router.DELETE("/me/sessions/:id", middleware.SessionOnly(), controllers.DeleteSession())
+	router.GET("/me/export", middleware.SessionOnly(), controllers.ExportData())
+	router.DELETE("/me", middleware.SessionOnly(), controllers.RequestAccountDeletion())
+	router.POST("/me/deletion/cancel", middleware.SessionOnly(), controllers.CancelAccountDeletion())
 	log.Fatal(router.Run(":" + port))
 }
\ No newline at end of file
@@ -1,25 +1,175 @@
 package middleware
 import (
+	"crypto/subtle"
+	"fmt"
 	"net/http"
+	"strings"
+	"ecommerce/config"
 	token "ecommerce/tokens"
 	"github.com/gin-gonic/gin"
 )
+const (
+	AdminHeader       = "X-Admin-Token"
+	CSRFHeader        = "X-CSRF-Token"
+	AuthMethodSession = "session"
+	AuthMethodAPIKey  = "api_key"
+	realm             = "ecommerce"
+)
+func AccessCookieName() string {
+	return config.String("AUTH_COOKIE_NAME", "access_token")
+}
+func CSRFCookieName() string {
+	return config.String("AUTH_CSRF_COOKIE_NAME", "csrf_token")
+}
 func Authentication() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ClientToken := c.Request.Header.Get("token")
+		ClientToken, fromCookie := extractToken(c)
 		if ClientToken == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "No Authorization Header Provided"})
-			c.Abort()
+			unauthorized(c, "", "No Authorization Header Provided")
+			return
+		}
+		if fromCookie && !validCSRF(c) {
+			csrfForbidden(c)
+			return
+		}
+		c.Set("auth_cookie", fromCookie)
+		if token.IsAPIKey(ClientToken) {
+			apikey, err := token.ValidateAPIKey(ClientToken)
+			if err != nil {
+				unauthorized(c, "invalid_token", err.Error())
+				return
+			}
+			email, err := token.APIKeyOwnerEmail(apikey)
+			if err != nil {
+				unauthorized(c, "invalid_token", err.Error())
+				return
+			}
+			c.Set("email", email)
+			c.Set("uid", apikey.User_ID)
+			c.Set("auth_method", AuthMethodAPIKey)
+			c.Set("scopes", apikey.Scopes)
+			c.Next()
 			return
 		}
 		claims, err := token.ValidateToken(ClientToken)
-		if err != "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
-			c.Abort()
+		if err != nil {
+			unauthorized(c, "invalid_token", err.Error())
+			return
+		}
+		if claims.Purpose != "" {
+			unauthorized(c, "invalid_token", "token cannot be used for authentication")
+			return
+		}
+		if token.TokenRevoked(claims) {
+			unauthorized(c, "invalid_token", "token has been revoked")
 			return
 		}
 		c.Set("email", claims.Email)
 		c.Set("uid", claims.Uid)
+		c.Set("sid", claims.Session_ID)
+		c.Set("auth_method", AuthMethodSession)
+		c.Next()
+	}
+}
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") != AuthMethodAPIKey {
+			c.Next()
+			return
+		}
+		for _, granted := range c.GetStringSlice("scopes") {
+			if granted == scope {
+				c.Next()
+				return
+			}
+		}
+		c.Header("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q, error=\"insufficient_scope\", scope=%q", realm, scope))
+		c.JSON(http.StatusForbidden, gin.H{"error": "api key is missing scope " + scope})
+		c.Abort()
+	}
+}
+func RequireCSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("auth_cookie") && !validCSRFToken(c) {
+			csrfForbidden(c)
+			return
+		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") == AuthMethodAPIKey {
+			c.JSON(http.StatusForbidden, gin.H{"error": "this endpoint cannot be used with an api key"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+func Admin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := config.String("ADMIN_TOKEN", "")
+		given := c.Request.Header.Get(AdminHeader)
+		if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(given)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+func extractToken(c *gin.Context) (string, bool) {
+	if header := c.Request.Header.Get("Authorization"); header != "" {
+		scheme, credentials, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(credentials), false
+		}
+		return "", false
+	}
+	if config.Bool("AUTH_LEGACY_TOKEN_HEADER", true) {
+		if legacy := c.Request.Header.Get("token"); legacy != "" {
+			return legacy, false
+		}
+	}
+	if config.Bool("AUTH_COOKIE_ENABLED", false) {
+		if cookie, err := c.Cookie(AccessCookieName()); err == nil && cookie != "" {
+			return cookie, true
+		}
+	}
+	return "", false
+}
+func validCSRF(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return validCSRFToken(c)
+}
+func validCSRFToken(c *gin.Context) bool {
+	cookie, err := c.Cookie(CSRFCookieName())
+	if err != nil || cookie == "" {
+		return false
+	}
+	header := c.Request.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+func csrfForbidden(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"error": "CSRF token missing or invalid"})
+	c.Abort()
+}
+func unauthorized(c *gin.Context, code string, description string) {
+	challenge := fmt.Sprintf("Bearer realm=%q", realm)
+	if code != "" {
+		challenge += fmt.Sprintf(", error=%q, error_description=%q", code, description)
+	}
+	c.Header("WWW-Authenticate", challenge)
+	c.JSON(http.StatusUnauthorized, gin.H{"error": description})
+	c.Abort()
+}
+func SetAuthCookies(c *gin.Context, accesstoken string, csrftoken string, maxAge int) {
+	secure := config.Bool("AUTH_COOKIE_SECURE", true)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(AccessCookieName(), accesstoken, maxAge, "/", "", secure, true)
+	c.SetCookie(CSRFCookieName(), csrftoken, maxAge, "/", "", secure, false)
+}
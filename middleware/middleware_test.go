@@ -36,9 +36,10 @@ func TestAuthenticationMiddleware(t *testing.T) {
 		req.Header.Set("token", "invalid-token")
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
 		expected := map[string]string{
-			"error": "token contains an invalid number of segments",
+			"error": "token is malformed",
 		}
 		expectedJSON, err := json.Marshal(expected)
 		if err != nil {
@@ -50,7 +51,129 @@ func TestAuthenticationMiddleware(t *testing.T) {
 		req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `Bearer realm="ecommerce"`, w.Header().Get("WWW-Authenticate"))
 		assert.Contains(t, w.Body.String(), `"error":"No Authorization Header Provided"`)
 	})
-}
\ No newline at end of file
+	t.Run("Bearer Token", func(t *testing.T) {
+		token, err := generateTestToken("test@example.com", "123456")
+		assert.NoError(t, err)
+		req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"uid":"123456"`)
+	})
+	t.Run("Legacy Header Disabled", func(t *testing.T) {
+		t.Setenv("AUTH_LEGACY_TOKEN_HEADER", "false")
+		token, err := generateTestToken("test@example.com", "123456")
+		assert.NoError(t, err)
+		req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("token", token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+func TestAuthenticationCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("AUTH_COOKIE_ENABLED", "true")
+	r := gin.Default()
+	r.POST("/protected", Authentication(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"uid": c.GetString("uid")})
+	})
+	token, err := generateTestToken("test@example.com", "123456")
+	assert.NoError(t, err)
+	newRequest := func(csrf string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/protected", nil)
+		req.AddCookie(&http.Cookie{Name: AccessCookieName(), Value: token})
+		req.AddCookie(&http.Cookie{Name: CSRFCookieName(), Value: "csrf-value"})
+		if csrf != "" {
+			req.Header.Set(CSRFHeader, csrf)
+		}
+		return req
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("csrf-value"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest(""))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newRequest("other-value"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	r.GET("/cartcheckout", Authentication(), RequireCSRF(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	newGet := func(csrf string) *http.Request {
+		req := newRequest(csrf)
+		req.Method = http.MethodGet
+		req.URL.Path = "/cartcheckout"
+		return req
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newGet(""))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newGet("csrf-value"))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(method string, scopes []string) *gin.Engine {
+		r := gin.New()
+		r.GET("/cart", func(c *gin.Context) {
+			c.Set("auth_method", method)
+			c.Set("scopes", scopes)
+		}, RequireScope("cart:read"), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return r
+	}
+	cases := []struct {
+		name     string
+		method   string
+		scopes   []string
+		expected int
+	}{
+		{"session ignores scopes", AuthMethodSession, nil, http.StatusOK},
+		{"api key with scope", AuthMethodAPIKey, []string{"cart:read"}, http.StatusOK},
+		{"api key without scope", AuthMethodAPIKey, []string{"cart:write"}, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/cart", nil)
+			newRouter(tc.method, tc.scopes).ServeHTTP(w, req)
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
+func TestAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/audit-log", Admin(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	cases := []struct {
+		name     string
+		secret   string
+		given    string
+		expected int
+	}{
+		{"unset secret denies", "", "", http.StatusForbidden},
+		{"wrong token", "s3cret", "guess", http.StatusForbidden},
+		{"correct token", "s3cret", "s3cret", http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("ADMIN_TOKEN", tc.secret)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/admin/audit-log", nil)
+			req.Header.Set(AdminHeader, tc.given)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package models
+import (
+	"time"
+	"ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+type UserResponse struct {
+	ID              primitive.ObjectID `json:"_id"`
+	First_Name      *string            `json:"first_name"`
+	Last_Name       *string            `json:"last_name"`
+	Email           *string            `json:"email"`
+	Phone           *string            `json:"phone"`
+	Created_At      time.Time          `json:"created_at"`
+	Updated_At      time.Time          `json:"updated_at"`
+	Verified_At     *time.Time         `json:"verified_at"`
+	Delete_After    *time.Time         `json:"deletion_scheduled_for,omitempty"`
+	Two_Factor      bool               `json:"two_factor_enabled"`
+	User_ID         string             `json:"user_id"`
+	UserCart        []ProductUser      `json:"usercart"`
+	Wishlist        []WishlistItem     `json:"wishlist"`
+	Address_Details []Address          `json:"address"`
+	Order_Status    []Order            `json:"orders"`
+}
+type LoginResponse struct {
+	User          UserResponse `json:"user"`
+	Token         string       `json:"token"`
+	Refresh_Token string       `json:"refresh_token"`
+}
+type ProfileUpdate struct {
+	First_Name *string `json:"first_name" validate:"omitempty,min=2,max=30"`
+	Last_Name  *string `json:"last_name"  validate:"omitempty,min=2,max=30"`
+	Phone      *string `json:"phone"      validate:"omitempty,min=1"`
+}
+type PasswordChange struct {
+	Current_Password string `json:"current_password" validate:"required"`
+	New_Password     string `json:"new_password"     validate:"required,min=6"`
+}
+func NewUserResponse(user User) UserResponse {
+	return UserResponse{
+		ID:              user.ID,
+		First_Name:      user.First_Name,
+		Last_Name:       user.Last_Name,
+		Email:           user.Email,
+		Phone:           user.Phone,
+		Created_At:      user.Created_At,
+		Updated_At:      user.Updated_At,
+		Verified_At:     user.Verified_At,
+		Delete_After:    user.Delete_After,
+		Two_Factor:      user.Totp_Enabled,
+		User_ID:         user.User_ID,
+		UserCart:        user.UserCart,
+		Wishlist:        user.Wishlist,
+		Address_Details: user.Address_Details,
+		Order_Status:    user.Order_Status,
+	}
+}
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+type ResetPasswordRequest struct {
+	Token    string `json:"token"    validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+type TwoFactorSetup struct {
+	Secret      string `json:"secret"`
+	OtpAuth_URI string `json:"otpauth_uri"`
+}
+type TwoFactorCode struct {
+	Code string `json:"code" validate:"required"`
+}
+type TwoFactorDisable struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code"     validate:"required"`
+}
+type TwoFactorLogin struct {
+	Challenge_Token string `json:"challenge_token" validate:"required"`
+	Code            string `json:"code"            validate:"required_without=Recovery_Code"`
+	Recovery_Code   string `json:"recovery_code"`
+}
+type TwoFactorChallenge struct {
+	Two_Factor_Required bool   `json:"two_factor_required"`
+	Challenge_Token     string `json:"challenge_token"`
+}
+type APIKeyCreate struct {
+	Name            string   `json:"name"            validate:"required,max=100"`
+	Scopes          []string `json:"scopes"          validate:"required,min=1,dive,oneof=profile:read profile:write cart:read cart:write address:write orders:read orders:write"`
+	Expires_In_Days int      `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+}
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key"`
+}
+type RefreshRequest struct {
+	Refresh_Token string `json:"refresh_token" validate:"required"`
+}
+type DataExport struct {
+	Generated_At       time.Time          `json:"generated_at"`
+	Profile            UserResponse       `json:"profile"`
+	Sessions           []Session          `json:"sessions"`
+	API_Keys           []APIKey           `json:"api_keys"`
+	Audit_Events       []AuditEvent       `json:"audit_events"`
+	Invoices           []Invoice          `json:"invoices"`
+	Shipments          []Shipment         `json:"shipments"`
+	Coupon_Redemptions []CouponRedemption `json:"coupon_redemptions"`
+	Guest_Carts        []GuestCart        `json:"guest_carts"`
+}
+type AccountDeletion struct {
+	Password string `json:"password" validate:"required"`
+}
+type WishlistAdd struct {
+	Product_ID string `json:"product_id" validate:"required,len=24,hexadecimal"`
+}
+type CouponApply struct {
+	Code string `json:"code" validate:"required,min=1,max=64"`
+}
+type CouponCreate struct {
+	Code              string      `json:"code"              validate:"required,min=3,max=64,alphanum"`
+	Type              string      `json:"type"              validate:"required,oneof=percentage fixed free_shipping"`
+	Value             int         `json:"value"             validate:"gte=0"`
+	Amount            money.Money `json:"amount"`
+	Min_Total         money.Money `json:"min_total"`
+	Product_IDs       []string    `json:"product_ids"       validate:"dive,len=24,hexadecimal"`
+	Category_IDs      []string    `json:"category_ids"      validate:"dive,len=24,hexadecimal"`
+	Max_Uses          *int        `json:"max_uses"          validate:"omitempty,gt=0"`
+	Max_Uses_Per_User *int        `json:"max_uses_per_user" validate:"omitempty,gt=0"`
+	Starts_At         *time.Time  `json:"starts_at"`
+	Ends_At           *time.Time  `json:"ends_at"`
+}
+type PromotionRuleInput struct {
+	Name         string          `json:"name"         validate:"required,min=2,max=100"`
+	Type         string          `json:"type"         validate:"required,oneof=buy_x_get_y bundle tiered"`
+	Priority     int             `json:"priority"`
+	Exclusive    bool            `json:"exclusive"`
+	Active       *bool           `json:"active"`
+	Product_IDs  []string        `json:"product_ids"  validate:"dive,len=24,hexadecimal"`
+	Buy_Quantity int             `json:"buy_quantity" validate:"gte=0"`
+	Get_Quantity int             `json:"get_quantity" validate:"gte=0"`
+	Bundle_Price money.Money     `json:"bundle_price"`
+	Tiers        []PromotionTier `json:"tiers"        validate:"dive"`
+	Starts_At    *time.Time      `json:"starts_at"`
+	Ends_At      *time.Time      `json:"ends_at"`
+}
+type PromotionExplain struct {
+	Subtotal money.Money  `json:"subtotal"`
+	Discount money.Money  `json:"discount"`
+	Total    money.Money  `json:"total"`
+	Rules    []RuleResult `json:"rules"`
+}
+type ExchangeRateCreate struct {
+	Base         string     `json:"base"         validate:"omitempty,len=3,alpha"`
+	Quote        string     `json:"quote"        validate:"required,len=3,alpha"`
+	Rate         string     `json:"rate"         validate:"required,numeric"`
+	Effective_At *time.Time `json:"effective_at"`
+}
+type TaxRuleCreate struct {
+	Name      string `json:"name"      validate:"required,max=100"`
+	Country   string `json:"country"   validate:"required,len=2,alpha"`
+	Region    string `json:"region"    validate:"omitempty,max=10"`
+	Category  string `json:"category"  validate:"omitempty,max=50"`
+	Rate      string `json:"rate"      validate:"required,numeric"`
+	Inclusive bool   `json:"inclusive"`
+}
+type ShippingMethodInput struct {
+	Code         string       `json:"code"         validate:"required,min=2,max=40,alphanum"`
+	Name         string       `json:"name"         validate:"required,max=100"`
+	Type         string       `json:"type"         validate:"required,oneof=flat weight free_over"`
+	Rate         money.Money  `json:"rate"`
+	Weight_Tiers []WeightTier `json:"weight_tiers" validate:"required_if=Type weight,dive"`
+	Free_Over    money.Money  `json:"free_over"`
+	Countries    []string     `json:"countries"    validate:"dive,len=2,alpha"`
+	Active       *bool        `json:"active"`
+}
+type ShipmentCreate struct {
+	Lines           []ShipmentLine `json:"lines"           validate:"required,min=1,dive"`
+	Carrier         string         `json:"carrier"         validate:"required,max=50"`
+	Tracking_Number string         `json:"tracking_number" validate:"required,max=100"`
+	Status          string         `json:"status"          validate:"omitempty,oneof=pending shipped"`
+}
+type ShipmentStatusUpdate struct {
+	Status string `json:"status" validate:"required,oneof=shipped in_transit delivered cancelled"`
+	Note   string `json:"note"   validate:"max=500"`
+}
+type CreditNoteCreate struct {
+	Amount *money.Money `json:"amount"`
+	Reason string       `json:"reason" validate:"required,max=500"`
+}
+type CategoryCreate struct {
+	Name      string `json:"name"      validate:"required,min=2,max=100"`
+	Slug      string `json:"slug"      validate:"omitempty,max=100"`
+	Parent_ID string `json:"parent_id" validate:"omitempty,len=24,hexadecimal"`
+	Position  int    `json:"position"`
+}
+type CategoryMove struct {
+	Parent_ID string `json:"parent_id" validate:"omitempty,len=24,hexadecimal"`
+}
+type ProductCategories struct {
+	Category_IDs []string `json:"category_ids" validate:"dive,len=24,hexadecimal"`
+}
+type ProductVariants struct {
+	Options  []ProductOption `json:"options"`
+	Variants []Variant       `json:"variants"`
+}
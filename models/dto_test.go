@@ -0,0 +1,27 @@
+package models
+import (
+	"encoding/json"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func TestNewUserResponseOmitsSecrets(t *testing.T) {
+	password := "$2a$14$hashedpassword"
+	token := "access-token"
+	refresh := "refresh-token"
+	email := "test@example.com"
+	user := User{
+		ID:            primitive.NewObjectID(),
+		Email:         &email,
+		Password:      &password,
+		Token:         &token,
+		Refresh_Token: &refresh,
+	}
+	body, err := json.Marshal(NewUserResponse(user))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), email)
+	assert.NotContains(t, string(body), password)
+	assert.NotContains(t, string(body), token)
+	assert.NotContains(t, string(body), refresh)
+}
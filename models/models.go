@@ -1,37 +1,269 @@
 package models
 import (
 	"time"
+	"ecommerce/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 type User struct {
-	ID              primitive.ObjectID `json:"_id" bson:"_id"`
-	First_Name      *string            `json:"first_name" validate:"required,min=2,max=30"`
-	Last_Name       *string            `json:"last_name"  validate:"required,min=2,max=30"`
-	Password        *string            `json:"password"   validate:"required,min=6"`
-	Email           *string            `json:"email"      validate:"email,required"`
-	Phone           *string            `json:"phone"      validate:"required"`
-	Token           *string            `json:"token"`
-	Refresh_Token   *string            `josn:"refresh_token"`
-	Created_At      time.Time          `json:"created_at"`
-	Updated_At      time.Time          `json:"updtaed_at"`
-	User_ID         string             `json:"user_id"`
-	UserCart        []ProductUser      `json:"usercart" bson:"usercart"`
-	Address_Details []Address          `json:"address" bson:"address"`
-	Order_Status    []Order            `json:"orders" bson:"orders"`
+	ID                primitive.ObjectID `json:"_id" bson:"_id"`
+	First_Name        *string            `json:"first_name" validate:"required,min=2,max=30"`
+	Last_Name         *string            `json:"last_name"  validate:"required,min=2,max=30"`
+	Password          *string            `json:"password"   validate:"required,min=6"`
+	Email             *string            `json:"email"      validate:"email,required"`
+	Phone             *string            `json:"phone"      validate:"required"`
+	Token             *string            `json:"token"`
+	Refresh_Token     *string            `josn:"refresh_token"`
+	Created_At        time.Time          `json:"created_at"`
+	Updated_At        time.Time          `json:"updtaed_at"`
+	User_ID           string             `json:"user_id"`
+	UserCart          []ProductUser      `json:"usercart" bson:"usercart"`
+	Wishlist          []WishlistItem     `json:"wishlist" bson:"wishlist"`
+	Applied_Coupon    *string            `json:"applied_coupon" bson:"applied_coupon,omitempty"`
+	Address_Details   []Address          `json:"address" bson:"address"`
+	Order_Status      []Order            `json:"orders" bson:"orders"`
+	Tokens_Revoked_At *time.Time         `json:"-" bson:"tokens_revoked_at,omitempty"`
+	Verified_At       *time.Time         `json:"verified_at" bson:"verified_at"`
+	Totp_Enabled      bool               `json:"-" bson:"totp_enabled"`
+	Totp_Secret       *string            `json:"-" bson:"totp_secret,omitempty"`
+	Totp_Pending      *string            `json:"-" bson:"totp_pending,omitempty"`
+	Totp_Last_Step    int64              `json:"-" bson:"totp_last_step"`
+	Recovery_Codes    []string           `json:"-" bson:"recovery_codes,omitempty"`
+	Delete_Requested  *time.Time         `json:"-" bson:"delete_requested,omitempty"`
+	Delete_After      *time.Time         `json:"-" bson:"delete_after,omitempty"`
+	Anonymized_At     *time.Time         `json:"-" bson:"anonymized_at,omitempty"`
 }
 type Product struct {
-	Product_ID   primitive.ObjectID `bson:"_id"`
-	Product_Name *string            `json:"product_name"`
-	Price        *uint64            `json:"price"`
-	Rating       *uint8             `json:"rating"`
-	Image        *string            `json:"image"`
+	Product_ID      primitive.ObjectID     `bson:"_id"`
+	Product_Name    *string                `json:"product_name"`
+	Price           *money.Money           `json:"price"`
+	Rating          *uint8                 `json:"rating"`
+	Image           *string                `json:"image"`
+	Stock           *int64                 `json:"stock" bson:"stock,omitempty"`
+	Category_IDs    []primitive.ObjectID   `json:"category_ids" bson:"category_ids,omitempty"`
+	Price_Overrides map[string]money.Money `json:"price_overrides,omitempty" bson:"price_overrides,omitempty"`
+	Display_Price   *money.Money           `json:"display_price,omitempty" bson:"-"`
+	Tax_Category    string                 `json:"tax_category" bson:"tax_category,omitempty"`
+	Weight_Grams    *int64                 `json:"weight_grams" bson:"weight_grams,omitempty"`
+	Dimensions      *Dimensions            `json:"dimensions" bson:"dimensions,omitempty"`
+	Options         []ProductOption        `json:"options,omitempty" bson:"options,omitempty"`
+	Variants        []Variant              `json:"variants,omitempty" bson:"variants,omitempty"`
+}
+type ProductOption struct {
+	Name   string   `json:"name"   bson:"name"`
+	Values []string `json:"values" bson:"values"`
+}
+type Variant struct {
+	SKU     string            `json:"sku"     bson:"sku"`
+	Options map[string]string `json:"options" bson:"options"`
+	Price   *money.Money      `json:"price"   bson:"price,omitempty"`
+	Stock   *int64            `json:"stock"   bson:"stock,omitempty"`
+	Image   *string           `json:"image"   bson:"image,omitempty"`
+	Barcode string            `json:"barcode" bson:"barcode,omitempty"`
+}
+type Category struct {
+	ID         primitive.ObjectID   `json:"_id"        bson:"_id"`
+	Name       string               `json:"name"       bson:"name"`
+	Slug       string               `json:"slug"       bson:"slug"`
+	Parent_ID  *primitive.ObjectID  `json:"parent_id"  bson:"parent_id"`
+	Ancestors  []primitive.ObjectID `json:"ancestors"  bson:"ancestors"`
+	Position   int                  `json:"position"   bson:"position"`
+	Created_At time.Time            `json:"created_at" bson:"created_at"`
+	Updated_At time.Time            `json:"updated_at" bson:"updated_at"`
+}
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+type Dimensions struct {
+	Length_Mm int64 `json:"length_mm" bson:"length_mm" validate:"gte=0"`
+	Width_Mm  int64 `json:"width_mm"  bson:"width_mm"  validate:"gte=0"`
+	Height_Mm int64 `json:"height_mm" bson:"height_mm" validate:"gte=0"`
 }
 type ProductUser struct {
-	Product_ID   primitive.ObjectID `bson:"_id"`
-	Product_Name *string            `json:"product_name" bson:"product_name"`
-	Price        int                `json:"price"  bson:"price"`
-	Rating       *uint              `json:"rating" bson:"rating"`
-	Image        *string            `json:"image"  bson:"image"`
+	Product_ID      primitive.ObjectID `bson:"_id"`
+	Product_Name    *string            `json:"product_name" bson:"product_name"`
+	Price           money.Money        `json:"price"  bson:"price"`
+	Rating          *uint              `json:"rating" bson:"rating"`
+	Image           *string            `json:"image"  bson:"image"`
+	Variant_SKU     string             `json:"variant_sku,omitempty" bson:"variant_sku,omitempty"`
+	Variant_Options map[string]string  `json:"variant_options,omitempty" bson:"variant_options,omitempty"`
+}
+type WishlistItem struct {
+	Product_ID    primitive.ObjectID `json:"product_id"    bson:"product_id"`
+	Product_Name  *string            `json:"product_name"  bson:"product_name"`
+	Image         *string            `json:"image"         bson:"image"`
+	Price_At_Add  money.Money        `json:"price_at_add"  bson:"price_at_add"`
+	Added_At      time.Time          `json:"added_at"      bson:"added_at"`
+	Current_Price *money.Money       `json:"current_price" bson:"-"`
+	Price_Dropped bool               `json:"price_dropped" bson:"-"`
+}
+type GuestCart struct {
+	ID         primitive.ObjectID `json:"_id"        bson:"_id"`
+	UserCart   []ProductUser      `json:"usercart"   bson:"usercart"`
+	Created_At time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At time.Time          `json:"updated_at" bson:"updated_at"`
+	Expires_At time.Time          `json:"expires_at" bson:"expires_at"`
+}
+const (
+	CartWarningPriceChanged = "price_changed"
+	CartWarningRemoved      = "removed"
+	CartWarningOutOfStock   = "out_of_stock"
+)
+type CartWarning struct {
+	Product_ID   primitive.ObjectID `json:"product_id"`
+	Variant_SKU  string             `json:"variant_sku,omitempty"`
+	Product_Name *string            `json:"product_name"`
+	Code         string             `json:"code"`
+	Old_Price    money.Money        `json:"old_price"`
+	New_Price    *money.Money       `json:"new_price,omitempty"`
+	Requested    int                `json:"requested,omitempty"`
+	Available    *int64             `json:"available,omitempty"`
+}
+type CartView struct {
+	Total            money.Money     `json:"total"`
+	UserCart         []ProductUser   `json:"usercart"`
+	Warnings         []CartWarning   `json:"warnings"`
+	Fingerprint      string          `json:"fingerprint,omitempty"`
+	Applied_Coupon   *string         `json:"applied_coupon,omitempty"`
+	Coupon_Error     string          `json:"coupon_error,omitempty"`
+	Discounts        []DiscountLine  `json:"discounts,omitempty"`
+	Discount         money.Money     `json:"discount"`
+	Free_Shipping    bool            `json:"free_shipping"`
+	Grand_Total      money.Money     `json:"grand_total"`
+	Currency         string          `json:"currency"`
+	Exchange_Rate    string          `json:"exchange_rate,omitempty"`
+	Tax              money.Money     `json:"tax"`
+	Tax_Lines        []TaxLine       `json:"tax_lines,omitempty"`
+	Shipping_Options []ShippingQuote `json:"shipping_options,omitempty"`
+	Shipping_Method  string          `json:"shipping_method,omitempty"`
+	Shipping_Cost    money.Money     `json:"shipping_cost"`
+}
+const (
+	ShippingFlat     = "flat"
+	ShippingWeight   = "weight"
+	ShippingFreeOver = "free_over"
+)
+type WeightTier struct {
+	Up_To_Grams int64       `json:"up_to_grams" bson:"up_to_grams" validate:"gt=0"`
+	Rate        money.Money `json:"rate"        bson:"rate"`
+}
+type ShippingMethod struct {
+	ID           primitive.ObjectID `json:"_id"          bson:"_id"`
+	Code         string             `json:"code"         bson:"code"`
+	Name         string             `json:"name"         bson:"name"`
+	Type         string             `json:"type"         bson:"type"`
+	Rate         money.Money        `json:"rate"         bson:"rate"`
+	Weight_Tiers []WeightTier       `json:"weight_tiers" bson:"weight_tiers,omitempty"`
+	Free_Over    money.Money        `json:"free_over"    bson:"free_over"`
+	Countries    []string           `json:"countries"    bson:"countries,omitempty"`
+	Active       bool               `json:"active"       bson:"active"`
+	Created_At   time.Time          `json:"created_at"   bson:"created_at"`
+}
+type ShippingQuote struct {
+	Code         string      `json:"code"`
+	Name         string      `json:"name"`
+	Cost         money.Money `json:"cost"`
+	Weight_Grams int64       `json:"weight_grams"`
+}
+const TaxCategoryExempt = "exempt"
+type TaxRule struct {
+	ID         primitive.ObjectID `json:"_id"        bson:"_id"`
+	Name       string             `json:"name"       bson:"name"`
+	Country    string             `json:"country"    bson:"country"`
+	Region     string             `json:"region"     bson:"region"`
+	Category   string             `json:"category"   bson:"category"`
+	Rate       string             `json:"rate"       bson:"rate"`
+	Inclusive  bool               `json:"inclusive"  bson:"inclusive"`
+	Created_At time.Time          `json:"created_at" bson:"created_at"`
+}
+type TaxLine struct {
+	Product_ID primitive.ObjectID `json:"product_id" bson:"product_id"`
+	Category   string             `json:"category"   bson:"category"`
+	Rule       string             `json:"rule"       bson:"rule"`
+	Rate       string             `json:"rate"       bson:"rate"`
+	Inclusive  bool               `json:"inclusive"  bson:"inclusive"`
+	Taxable    money.Money        `json:"taxable"    bson:"taxable"`
+	Tax        money.Money        `json:"tax"        bson:"tax"`
+}
+type ExchangeRate struct {
+	ID           primitive.ObjectID `json:"_id"          bson:"_id"`
+	Base         string             `json:"base"         bson:"base"`
+	Quote        string             `json:"quote"        bson:"quote"`
+	Rate         string             `json:"rate"         bson:"rate"`
+	Effective_At time.Time          `json:"effective_at" bson:"effective_at"`
+	Created_At   time.Time          `json:"created_at"   bson:"created_at"`
+}
+const (
+	CouponPercentage   = "percentage"
+	CouponFixed        = "fixed"
+	CouponFreeShipping = "free_shipping"
+)
+type Coupon struct {
+	ID                primitive.ObjectID   `json:"_id"               bson:"_id"`
+	Code              string               `json:"code"              bson:"code"`
+	Type              string               `json:"type"              bson:"type"`
+	Value             int                  `json:"value"             bson:"value"`
+	Amount            money.Money          `json:"amount"            bson:"amount"`
+	Min_Total         money.Money          `json:"min_total"         bson:"min_total"`
+	Product_IDs       []primitive.ObjectID `json:"product_ids"       bson:"product_ids,omitempty"`
+	Category_IDs      []primitive.ObjectID `json:"category_ids"      bson:"category_ids,omitempty"`
+	Max_Uses          *int                 `json:"max_uses"          bson:"max_uses,omitempty"`
+	Max_Uses_Per_User *int                 `json:"max_uses_per_user" bson:"max_uses_per_user,omitempty"`
+	Uses              int                  `json:"uses"              bson:"uses"`
+	Starts_At         *time.Time           `json:"starts_at"         bson:"starts_at,omitempty"`
+	Ends_At           *time.Time           `json:"ends_at"           bson:"ends_at,omitempty"`
+	Active            bool                 `json:"active"            bson:"active"`
+	Created_At        time.Time            `json:"created_at"        bson:"created_at"`
+}
+type CouponRedemption struct {
+	ID         primitive.ObjectID `json:"_id"        bson:"_id"`
+	Coupon_ID  primitive.ObjectID `json:"coupon_id"  bson:"coupon_id"`
+	Code       string             `json:"code"       bson:"code"`
+	User_ID    string             `json:"user_id"    bson:"user_id"`
+	Order_ID   primitive.ObjectID `json:"order_id"   bson:"order_id"`
+	Amount     money.Money        `json:"amount"     bson:"amount"`
+	Created_At time.Time          `json:"created_at" bson:"created_at"`
+}
+const (
+	RuleBuyXGetY = "buy_x_get_y"
+	RuleBundle   = "bundle"
+	RuleTiered   = "tiered"
+)
+type PromotionTier struct {
+	Min_Total money.Money `json:"min_total" bson:"min_total"`
+	Percent   int         `json:"percent"   bson:"percent"   validate:"min=1,max=100"`
+}
+type PromotionRule struct {
+	ID           primitive.ObjectID   `json:"_id"          bson:"_id"`
+	Name         string               `json:"name"         bson:"name"`
+	Type         string               `json:"type"         bson:"type"`
+	Priority     int                  `json:"priority"     bson:"priority"`
+	Exclusive    bool                 `json:"exclusive"    bson:"exclusive"`
+	Active       bool                 `json:"active"       bson:"active"`
+	Product_IDs  []primitive.ObjectID `json:"product_ids"  bson:"product_ids,omitempty"`
+	Buy_Quantity int                  `json:"buy_quantity" bson:"buy_quantity,omitempty"`
+	Get_Quantity int                  `json:"get_quantity" bson:"get_quantity,omitempty"`
+	Bundle_Price money.Money          `json:"bundle_price" bson:"bundle_price"`
+	Tiers        []PromotionTier      `json:"tiers"        bson:"tiers,omitempty"`
+	Starts_At    *time.Time           `json:"starts_at"    bson:"starts_at,omitempty"`
+	Ends_At      *time.Time           `json:"ends_at"      bson:"ends_at,omitempty"`
+	Created_At   time.Time            `json:"created_at"   bson:"created_at"`
+	Updated_At   time.Time            `json:"updated_at"   bson:"updated_at"`
+}
+type RuleResult struct {
+	Rule_ID primitive.ObjectID `json:"rule_id"`
+	Name    string             `json:"name"`
+	Type    string             `json:"type"`
+	Fired   bool               `json:"fired"`
+	Amount  money.Money        `json:"amount"`
+	Reason  string             `json:"reason"`
+}
+type DiscountLine struct {
+	Source        string      `json:"source"        bson:"source"`
+	Code          string      `json:"code"          bson:"code"`
+	Description   string      `json:"description"   bson:"description"`
+	Amount        money.Money `json:"amount"        bson:"amount"`
+	Free_Shipping bool        `json:"free_shipping" bson:"free_shipping"`
 }
 type Address struct {
 	Address_id primitive.ObjectID `bson:"_id"`
@@ -39,16 +271,169 @@ type Address struct {
 	Street     *string            `json:"street_name" bson:"street_name"`
 	City       *string            `json:"city_name" bson:"city_name"`
 	Pincode    *string            `json:"pin_code" bson:"pin_code"`
+	Country    *string            `json:"country" bson:"country" validate:"omitempty,len=2,alpha"`
+	Region     *string            `json:"region" bson:"region" validate:"omitempty,max=10"`
 }
 type Order struct {
-	Order_ID       primitive.ObjectID `bson:"_id"`
-	Order_Cart     []ProductUser      `json:"order_list"  bson:"order_list"`
-	Orderered_At   time.Time          `json:"ordered_on"  bson:"ordered_on"`
-	Price          int                `json:"total_price" bson:"total_price"`
-	Discount       *int               `json:"discount"    bson:"discount"`
-	Payment_Method Payment            `json:"payment_method" bson:"payment_method"`
+	Order_ID          primitive.ObjectID `bson:"_id"`
+	Order_Cart        []ProductUser      `json:"order_list"  bson:"order_list"`
+	Orderered_At      time.Time          `json:"ordered_on"  bson:"ordered_on"`
+	Subtotal          money.Money        `json:"subtotal"    bson:"subtotal"`
+	Price             money.Money        `json:"total_price" bson:"total_price"`
+	Discount          *money.Money       `json:"discount"    bson:"discount,omitempty"`
+	Discounts         []DiscountLine     `json:"discounts"   bson:"discounts,omitempty"`
+	Free_Shipping     bool               `json:"free_shipping" bson:"free_shipping"`
+	Payment_Method    Payment            `json:"payment_method" bson:"payment_method"`
+	Currency          string             `json:"currency"          bson:"currency,omitempty"`
+	Exchange_Rate     string             `json:"exchange_rate"     bson:"exchange_rate,omitempty"`
+	Rate_Effective_At *time.Time         `json:"rate_effective_at" bson:"rate_effective_at,omitempty"`
+	Tax_Lines         []TaxLine          `json:"tax_lines"         bson:"tax_lines,omitempty"`
+	Tax               money.Money        `json:"tax"               bson:"tax"`
+	Tax_Jurisdiction  string             `json:"tax_jurisdiction"  bson:"tax_jurisdiction,omitempty"`
+	Shipping_Method   string             `json:"shipping_method"   bson:"shipping_method,omitempty"`
+	Shipping_Cost     money.Money        `json:"shipping_cost"     bson:"shipping_cost"`
+	Status            string             `json:"status"            bson:"status,omitempty"`
+	Shipped_At        *time.Time         `json:"shipped_at"        bson:"shipped_at,omitempty"`
+	Delivered_At      *time.Time         `json:"delivered_at"      bson:"delivered_at,omitempty"`
+	Shipping_Address  *Address           `json:"shipping_address"  bson:"shipping_address,omitempty"`
+}
+const (
+	OrderUnfulfilled      = "unfulfilled"
+	OrderPartiallyShipped = "partially_shipped"
+	OrderShipped          = "shipped"
+	OrderDelivered        = "delivered"
+)
+const (
+	InvoiceKindInvoice    = "invoice"
+	InvoiceKindCreditNote = "credit_note"
+)
+type InvoiceLine struct {
+	Product_ID  primitive.ObjectID `json:"product_id"  bson:"product_id,omitempty"`
+	SKU         string             `json:"sku"         bson:"sku,omitempty"`
+	Description string             `json:"description" bson:"description"`
+	Quantity    int                `json:"quantity"    bson:"quantity"`
+	Unit_Price  money.Money        `json:"unit_price"  bson:"unit_price"`
+	Total       money.Money        `json:"total"       bson:"total"`
+}
+type Invoice struct {
+	ID               primitive.ObjectID `json:"_id"              bson:"_id"`
+	Number           string             `json:"number"           bson:"number"`
+	Kind             string             `json:"kind"             bson:"kind"`
+	Year             int                `json:"year"             bson:"year"`
+	Sequence         int64              `json:"sequence"         bson:"sequence"`
+	Order_ID         primitive.ObjectID `json:"order_id"         bson:"order_id"`
+	User_ID          string             `json:"user_id"          bson:"user_id"`
+	Issued_At        time.Time          `json:"issued_at"        bson:"issued_at"`
+	Customer_Name    string             `json:"customer_name"    bson:"customer_name"`
+	Customer_Email   string             `json:"customer_email"   bson:"customer_email"`
+	Address          *Address           `json:"address"          bson:"address,omitempty"`
+	Currency         string             `json:"currency"         bson:"currency"`
+	Lines            []InvoiceLine      `json:"lines"            bson:"lines"`
+	Subtotal         money.Money        `json:"subtotal"         bson:"subtotal"`
+	Discounts        []DiscountLine     `json:"discounts"        bson:"discounts,omitempty"`
+	Shipping_Method  string             `json:"shipping_method"  bson:"shipping_method,omitempty"`
+	Shipping         money.Money        `json:"shipping"         bson:"shipping"`
+	Tax              money.Money        `json:"tax"              bson:"tax"`
+	Tax_Inclusive    bool               `json:"tax_inclusive"    bson:"tax_inclusive"`
+	Tax_Jurisdiction string             `json:"tax_jurisdiction" bson:"tax_jurisdiction,omitempty"`
+	Total            money.Money        `json:"total"            bson:"total"`
+	Payment_Method   string             `json:"payment_method"   bson:"payment_method"`
+	Credits_Invoice  string             `json:"credits_invoice"  bson:"credits_invoice,omitempty"`
+	Credited         money.Money        `json:"credited"         bson:"credited,omitempty"`
+	Reason           string             `json:"reason"           bson:"reason,omitempty"`
+}
+const (
+	ShipmentPending   = "pending"
+	ShipmentShipped   = "shipped"
+	ShipmentInTransit = "in_transit"
+	ShipmentDelivered = "delivered"
+	ShipmentCancelled = "cancelled"
+)
+type ShipmentLine struct {
+	Product_ID  primitive.ObjectID `json:"product_id"            bson:"product_id"`
+	Variant_SKU string             `json:"variant_sku,omitempty" bson:"variant_sku,omitempty"`
+	Quantity    int                `json:"quantity"              bson:"quantity"              validate:"gt=0"`
+}
+type ShipmentEvent struct {
+	Status string    `json:"status" bson:"status"`
+	Note   string    `json:"note"   bson:"note,omitempty"`
+	At     time.Time `json:"at"     bson:"at"`
+}
+type Shipment struct {
+	ID              primitive.ObjectID `json:"_id"             bson:"_id"`
+	Order_ID        primitive.ObjectID `json:"order_id"        bson:"order_id"`
+	User_ID         string             `json:"user_id"         bson:"user_id"`
+	Sequence        int64              `json:"sequence"        bson:"sequence,omitempty"`
+	Lines           []ShipmentLine     `json:"lines"           bson:"lines"`
+	Carrier         string             `json:"carrier"         bson:"carrier"`
+	Tracking_Number string             `json:"tracking_number" bson:"tracking_number"`
+	Status          string             `json:"status"          bson:"status"`
+	Events          []ShipmentEvent    `json:"events"          bson:"events"`
+	Created_At      time.Time          `json:"created_at"      bson:"created_at"`
+	Updated_At      time.Time          `json:"updated_at"      bson:"updated_at"`
 }
 type Payment struct {
 	Digital bool `json:"digital" bson:"digital"`
 	COD     bool `json:"cod"     bson:"cod"`
-}
\ No newline at end of file
+}
+const (
+	PurposePasswordReset     = "password_reset"
+	PurposeEmailVerification = "email_verification"
+)
+type UserToken struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	User_ID    string             `bson:"user_id"`
+	Purpose    string             `bson:"purpose"`
+	Token_Hash string             `bson:"token_hash"`
+	Created_At time.Time          `bson:"created_at"`
+	Expires_At time.Time          `bson:"expires_at"`
+	Used_At    *time.Time         `bson:"used_at"`
+}
+type AuditEvent struct {
+	ID         primitive.ObjectID     `json:"_id"        bson:"_id"`
+	Type       string                 `json:"type"       bson:"type"`
+	User_ID    string                 `json:"user_id"    bson:"user_id,omitempty"`
+	Actor      string                 `json:"actor"      bson:"actor,omitempty"`
+	IP         string                 `json:"ip"         bson:"ip,omitempty"`
+	Details    map[string]interface{} `json:"details"    bson:"details,omitempty"`
+	Created_At time.Time              `json:"created_at" bson:"created_at"`
+}
+type LoginAttempt struct {
+	Key          string     `bson:"_id"`
+	Failures     int        `bson:"failures"`
+	Last_Failure time.Time  `bson:"last_failure"`
+	Locked_Until *time.Time `bson:"locked_until,omitempty"`
+}
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+	ScopeCartRead     = "cart:read"
+	ScopeCartWrite    = "cart:write"
+	ScopeAddressWrite = "address:write"
+	ScopeOrdersRead   = "orders:read"
+	ScopeOrdersWrite  = "orders:write"
+)
+type APIKey struct {
+	ID           primitive.ObjectID `json:"_id"          bson:"_id"`
+	User_ID      string             `json:"user_id"      bson:"user_id"`
+	Name         string             `json:"name"         bson:"name"`
+	Prefix       string             `json:"prefix"       bson:"prefix"`
+	Secret_Hash  string             `json:"-"            bson:"secret_hash"`
+	Scopes       []string           `json:"scopes"       bson:"scopes"`
+	Expires_At   *time.Time         `json:"expires_at"   bson:"expires_at"`
+	Last_Used_At *time.Time         `json:"last_used_at" bson:"last_used_at"`
+	Created_At   time.Time          `json:"created_at"   bson:"created_at"`
+}
+type Session struct {
+	ID           primitive.ObjectID `json:"_id"          bson:"_id"`
+	User_ID      string             `json:"-"            bson:"user_id"`
+	Device_Name  string             `json:"device_name"  bson:"device_name"`
+	User_Agent   string             `json:"user_agent"   bson:"user_agent"`
+	IP           string             `json:"ip"           bson:"ip"`
+	Refresh_Hash string             `json:"-"            bson:"refresh_hash"`
+	Created_At   time.Time          `json:"created_at"   bson:"created_at"`
+	Last_Seen_At time.Time          `json:"last_seen_at" bson:"last_seen_at"`
+	Expires_At   time.Time          `json:"expires_at"   bson:"expires_at"`
+	Revoked_At   *time.Time         `json:"-"            bson:"revoked_at"`
+	Current      bool               `json:"current"      bson:"-"`
+}
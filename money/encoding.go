@@ -0,0 +1,131 @@
+package money
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+type document struct {
+	Amount   int64  `json:"amount"   bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+func (m *Money) set(amount int64, currency string) error {
+	if currency == "" {
+		currency = DefaultCurrency()
+	}
+	currency = strings.ToUpper(currency)
+	if !ValidCurrency(currency) {
+		return ErrInvalidCurrency
+	}
+	m.Amount = amount
+	m.Currency = currency
+	return nil
+}
+func (m *Money) setLegacy(units int64) error {
+	currency := LegacyCurrency()
+	scale := int64(math.Pow10(Exponent(currency)))
+	if units > math.MaxInt64/scale || units < math.MinInt64/scale {
+		return ErrOverflow
+	}
+	return m.set(units*scale, currency)
+}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(document{Amount: m.Amount, Currency: m.Currency})
+}
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "null" {
+		return nil
+	}
+	if strings.HasPrefix(trimmed, "{") {
+		var doc document
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		return m.set(doc.Amount, doc.Currency)
+	}
+	var legacy json.Number
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return ErrInvalidAmount
+	}
+	units, err := legacy.Int64()
+	if err != nil {
+		return ErrInvalidAmount
+	}
+	return m.setLegacy(units)
+}
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(document{Amount: m.Amount, Currency: m.Currency})
+}
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	value := bsoncore.Value{Type: t, Data: data}
+	if t == bsontype.EmbeddedDocument {
+		var doc document
+		if err := bson.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		return m.set(doc.Amount, doc.Currency)
+	}
+	if t == bsontype.Null || t == bsontype.Undefined {
+		return nil
+	}
+	units, err := AmountFromBSON(value)
+	if err != nil {
+		return err
+	}
+	return m.setLegacy(units)
+}
+func AmountFromBSON(value bsoncore.Value) (int64, error) {
+	switch value.Type {
+	case bsontype.Int32:
+		return int64(value.Int32()), nil
+	case bsontype.Int64:
+		return value.Int64(), nil
+	case bsontype.Double:
+		return amountFromFloat(value.Double())
+	case bsontype.Decimal128:
+		return AmountFromNumber(value.Decimal128())
+	}
+	return 0, fmt.Errorf("%w: cannot decode BSON %s as money", ErrInvalidAmount, value.Type)
+}
+func AmountFromNumber(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, ErrOverflow
+		}
+		return int64(v), nil
+	case float64:
+		return amountFromFloat(v)
+	case primitive.Decimal128:
+		rat, ok := new(big.Rat).SetString(v.String())
+		if !ok || !rat.IsInt() {
+			return 0, ErrInvalidAmount
+		}
+		if !rat.Num().IsInt64() {
+			return 0, ErrOverflow
+		}
+		return rat.Num().Int64(), nil
+	}
+	return 0, fmt.Errorf("%w: unsupported type %T", ErrInvalidAmount, value)
+}
+func amountFromFloat(v float64) (int64, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) || v != math.Trunc(v) {
+		return 0, ErrInvalidAmount
+	}
+	if v >= math.MaxInt64 || v < math.MinInt64 {
+		return 0, ErrOverflow
+	}
+	return int64(v), nil
+}
@@ -0,0 +1,233 @@
+package money
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"ecommerce/config"
+)
+type RoundingMode int
+const (
+	RoundHalfEven RoundingMode = iota
+	RoundHalfUp
+	RoundDown
+	RoundUp
+)
+var (
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+	ErrOverflow         = errors.New("money: amount overflows int64")
+	ErrInvalidCurrency  = errors.New("money: invalid currency code")
+	ErrInvalidAmount    = errors.New("money: invalid amount")
+	ErrDivisionByZero   = errors.New("money: division by zero")
+)
+var exponents = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+type Money struct {
+	Amount   int64
+	Currency string
+}
+func DefaultCurrency() string {
+	currency := strings.ToUpper(config.String("DEFAULT_CURRENCY", "USD"))
+	if ValidCurrency(currency) {
+		return currency
+	}
+	return "USD"
+}
+func LegacyCurrency() string {
+	currency := strings.ToUpper(config.String("LEGACY_CURRENCY", "USD"))
+	if ValidCurrency(currency) {
+		return currency
+	}
+	return "USD"
+}
+func ValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+func Exponent(currency string) int {
+	if exponent, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+func New(amount int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency()
+	}
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+func (m Money) unset() bool {
+	return m.Amount == 0 && m.Currency == ""
+}
+func (m Money) Compatible(o Money) bool {
+	return m.Currency == o.Currency || m.unset() || o.unset()
+}
+func (m Money) currencyWith(o Money) string {
+	if m.Currency == "" {
+		return o.Currency
+	}
+	return m.Currency
+}
+func (m Money) Add(o Money) (Money, error) {
+	if !m.Compatible(o) {
+		return m, ErrCurrencyMismatch
+	}
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return m, ErrOverflow
+	}
+	return Money{Amount: sum, Currency: m.currencyWith(o)}, nil
+}
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return m, ErrOverflow
+	}
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return Money{Amount: 0, Currency: m.Currency}, nil
+	}
+	product := m.Amount * n
+	if product/n != m.Amount || (m.Amount == -1 && n == math.MinInt64) || (n == -1 && m.Amount == math.MinInt64) {
+		return m, ErrOverflow
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+func (m Money) MulRat(numerator, denominator int64, mode RoundingMode) (Money, error) {
+	if denominator == 0 {
+		return m, ErrDivisionByZero
+	}
+	scaled := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(numerator))
+	result := divRound(scaled, big.NewInt(denominator), mode)
+	if !result.IsInt64() {
+		return m, ErrOverflow
+	}
+	return Money{Amount: result.Int64(), Currency: m.Currency}, nil
+}
+func (m Money) Percent(percent int64, mode RoundingMode) (Money, error) {
+	return m.MulRat(percent, 100, mode)
+}
+func divRound(numerator, denominator *big.Int, mode RoundingMode) *big.Int {
+	if denominator.Sign() < 0 {
+		numerator = new(big.Int).Neg(numerator)
+		denominator = new(big.Int).Neg(denominator)
+	}
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+	away := big.NewInt(int64(numerator.Sign()))
+	twice := new(big.Int).Abs(new(big.Int).Mul(remainder, big.NewInt(2)))
+	switch mode {
+	case RoundDown:
+		return quotient
+	case RoundUp:
+		return quotient.Add(quotient, away)
+	case RoundHalfUp:
+		if twice.Cmp(denominator) >= 0 {
+			return quotient.Add(quotient, away)
+		}
+	default:
+		switch twice.Cmp(denominator) {
+		case 1:
+			return quotient.Add(quotient, away)
+		case 0:
+			if quotient.Bit(0) == 1 {
+				return quotient.Add(quotient, away)
+			}
+		}
+	}
+	return quotient
+}
+func (m Money) Cmp(o Money) (int, error) {
+	if !m.Compatible(o) {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+func (m Money) LessThan(o Money) bool {
+	cmp, err := m.Cmp(o)
+	return err == nil && cmp < 0
+}
+func Min(a, b Money) Money {
+	if b.LessThan(a) {
+		return b
+	}
+	return a
+}
+func Sum(currency string, items ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, item := range items {
+		var err error
+		if total, err = total.Add(item); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+func Parse(value string, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if !ValidCurrency(currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, ErrInvalidAmount
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(Exponent(currency))), nil)
+	scaled := new(big.Rat).Mul(rat, new(big.Rat).SetInt(scale))
+	if !scaled.IsInt() {
+		return Money{}, ErrInvalidAmount
+	}
+	if !scaled.Num().IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: scaled.Num().Int64(), Currency: currency}, nil
+}
+func (m Money) Major() string {
+	exponent := Exponent(m.Currency)
+	sign := ""
+	amount := new(big.Int).SetInt64(m.Amount)
+	if amount.Sign() < 0 {
+		sign = "-"
+		amount.Neg(amount)
+	}
+	digits := amount.String()
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.Major(), m.Currency)
+}
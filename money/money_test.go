@@ -0,0 +1,132 @@
+package money
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+func TestArithmetic(t *testing.T) {
+	a := New(1050, "usd")
+	b := New(250, "USD")
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	assert.Equal(t, Money{Amount: 1300, Currency: "USD"}, sum)
+	diff, err := a.Sub(b)
+	require.NoError(t, err)
+	assert.Equal(t, int64(800), diff.Amount)
+	_, err = a.Add(New(1, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	sum, err = Money{}.Add(a)
+	require.NoError(t, err)
+	assert.Equal(t, a, sum)
+	triple, err := a.Mul(3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3150), triple.Amount)
+}
+func TestOverflow(t *testing.T) {
+	_, err := New(math.MaxInt64, "USD").Add(New(1, "USD"))
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MinInt64, "USD").Sub(New(1, "USD"))
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MaxInt64/2+1, "USD").Mul(2)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MaxInt64, "USD").MulRat(3, 2, RoundHalfEven)
+	assert.ErrorIs(t, err, ErrOverflow)
+	half, err := New(math.MaxInt64, "USD").MulRat(1, 2, RoundDown)
+	require.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt64/2), half.Amount)
+}
+func TestRounding(t *testing.T) {
+	cases := []struct {
+		amount   int64
+		mode     RoundingMode
+		expected int64
+	}{
+		{25, RoundHalfEven, 2},
+		{35, RoundHalfEven, 4},
+		{-25, RoundHalfEven, -2},
+		{25, RoundHalfUp, 3},
+		{-25, RoundHalfUp, -3},
+		{29, RoundDown, 2},
+		{21, RoundUp, 3},
+		{26, RoundHalfEven, 3},
+	}
+	for _, tc := range cases {
+		result, err := New(tc.amount, "USD").MulRat(1, 10, tc.mode)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, result.Amount, "%d mode %d", tc.amount, tc.mode)
+	}
+	fifteen, err := New(999, "USD").Percent(15, RoundHalfEven)
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), fifteen.Amount)
+}
+func TestParseAndFormat(t *testing.T) {
+	m, err := Parse("12.34", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234), m.Amount)
+	assert.Equal(t, "12.34 USD", m.String())
+	m, err = Parse("500", "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, "500 JPY", m.String())
+	assert.Equal(t, "0.005 KWD", New(5, "KWD").String())
+	assert.Equal(t, "-0.05 EUR", New(-5, "EUR").String())
+	_, err = Parse("1.234", "USD")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	_, err = Parse("1", "dollars")
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+}
+func TestJSON(t *testing.T) {
+	encoded, err := json.Marshal(New(1234, "EUR"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":1234,"currency":"EUR"}`, string(encoded))
+	var decoded Money
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, New(1234, "EUR"), decoded)
+	t.Setenv("DEFAULT_CURRENCY", "gbp")
+	require.NoError(t, json.Unmarshal([]byte(`750`), &decoded))
+	assert.Equal(t, New(75000, "USD"), decoded)
+	assert.Error(t, json.Unmarshal([]byte(`7.5`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":1,"currency":"$$"}`), &decoded))
+}
+func TestBSON(t *testing.T) {
+	type line struct {
+		Price Money  `bson:"price"`
+		Was   *Money `bson:"was,omitempty"`
+	}
+	encoded, err := bson.Marshal(line{Price: New(999, "USD")})
+	require.NoError(t, err)
+	var decoded line
+	require.NoError(t, bson.Unmarshal(encoded, &decoded))
+	assert.Equal(t, New(999, "USD"), decoded.Price)
+	assert.Nil(t, decoded.Was)
+	for _, legacy := range []interface{}{int32(120), int64(120), float64(120)} {
+		encoded, err = bson.Marshal(bson.M{"price": legacy})
+		require.NoError(t, err)
+		require.NoError(t, bson.Unmarshal(encoded, &decoded))
+		assert.Equal(t, New(12000, "USD"), decoded.Price)
+	}
+	encoded, err = bson.Marshal(bson.M{"price": 1.5})
+	require.NoError(t, err)
+	assert.Error(t, bson.Unmarshal(encoded, &decoded))
+}
+func TestBSONBaselineDocument(t *testing.T) {
+	t.Setenv("DEFAULT_CURRENCY", "EUR")
+	type product struct {
+		Price Money `bson:"price"`
+	}
+	type order struct {
+		Price Money     `bson:"total_price"`
+		Cart  []product `bson:"order_list"`
+	}
+	encoded, err := bson.Marshal(bson.M{"total_price": int64(500), "order_list": bson.A{bson.M{"price": int32(250)}, bson.M{"price": int32(250)}}})
+	require.NoError(t, err)
+	var decoded order
+	require.NoError(t, bson.Unmarshal(encoded, &decoded))
+	assert.Equal(t, New(50000, "USD"), decoded.Price)
+	assert.Equal(t, []product{{Price: New(25000, "USD")}, {Price: New(25000, "USD")}}, decoded.Cart)
+	t.Setenv("LEGACY_CURRENCY", "JPY")
+	require.NoError(t, bson.Unmarshal(encoded, &decoded))
+	assert.Equal(t, New(500, "JPY"), decoded.Price)
+}
@@ -0,0 +1,120 @@
+package passwords
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"ecommerce/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+	BcryptMaxBytes    = 72
+)
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+	ErrMalformedHash    = errors.New("stored password hash is malformed")
+	ErrPasswordTooLong  = errors.New("password is too long for the configured algorithm")
+)
+type Params struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	Argon2SaltLength  uint32
+	Argon2KeyLength   uint32
+}
+type Hasher struct {
+	Params Params
+}
+func FromEnv() *Hasher {
+	return &Hasher{Params: Params{
+		Algorithm:         config.String("PASSWORD_ALGORITHM", AlgorithmBcrypt),
+		BcryptCost:        config.Int("PASSWORD_BCRYPT_COST", 12),
+		Argon2Memory:      uint32(config.Int("PASSWORD_ARGON2_MEMORY_KIB", 64*1024)),
+		Argon2Iterations:  uint32(config.Int("PASSWORD_ARGON2_ITERATIONS", 3)),
+		Argon2Parallelism: uint8(config.Int("PASSWORD_ARGON2_PARALLELISM", 2)),
+		Argon2SaltLength:  16,
+		Argon2KeyLength:   32,
+	}}
+}
+func (h *Hasher) Hash(password string) (string, error) {
+	switch h.Params.Algorithm {
+	case AlgorithmBcrypt:
+		if len(password) > BcryptMaxBytes {
+			return "", ErrPasswordTooLong
+		}
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Params.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	case AlgorithmArgon2id:
+		salt := make([]byte, h.Params.Argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, h.Params.Argon2Iterations, h.Params.Argon2Memory, h.Params.Argon2Parallelism, h.Params.Argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, h.Params.Argon2Memory, h.Params.Argon2Iterations, h.Params.Argon2Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+	return "", ErrUnknownAlgorithm
+}
+func (h *Hasher) Verify(password string, encoded string) (valid bool, needsRehash bool, err error) {
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false, nil
+		}
+		return true, h.Params.Algorithm != AlgorithmArgon2id ||
+			params.Argon2Memory != h.Params.Argon2Memory ||
+			params.Argon2Iterations != h.Params.Argon2Iterations ||
+			params.Argon2Parallelism != h.Params.Argon2Parallelism ||
+			uint32(len(key)) != h.Params.Argon2KeyLength, nil
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, false, ErrMalformedHash
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	return true, h.Params.Algorithm != AlgorithmBcrypt || cost != h.Params.BcryptCost, nil
+}
+func decodeArgon2id(encoded string) (Params, []byte, []byte, error) {
+	var params Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrMalformedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Iterations, &params.Argon2Parallelism); err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrMalformedHash
+	}
+	params.Algorithm = AlgorithmArgon2id
+	return params, salt, key, nil
+}
@@ -0,0 +1,87 @@
+package passwords
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+func bcryptHasher(cost int) *Hasher {
+	return &Hasher{Params: Params{Algorithm: AlgorithmBcrypt, BcryptCost: cost}}
+}
+func argonHasher(memory uint32) *Hasher {
+	return &Hasher{Params: Params{
+		Algorithm:         AlgorithmArgon2id,
+		Argon2Memory:      memory,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		Argon2SaltLength:  16,
+		Argon2KeyLength:   32,
+	}}
+}
+func TestBcryptHashAndVerify(t *testing.T) {
+	h := bcryptHasher(bcrypt.MinCost)
+	encoded, err := h.Hash("correct horse")
+	require.NoError(t, err)
+	valid, rehash, err := h.Verify("correct horse", encoded)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.False(t, rehash)
+	valid, _, err = h.Verify("wrong horse", encoded)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	_, rehash, err = bcryptHasher(bcrypt.MinCost+1).Verify("correct horse", encoded)
+	require.NoError(t, err)
+	assert.True(t, rehash)
+	_, err = h.Hash(strings.Repeat("a", 73))
+	assert.ErrorIs(t, err, ErrPasswordTooLong)
+}
+func TestArgon2idHashAndVerify(t *testing.T) {
+	h := argonHasher(8 * 1024)
+	encoded, err := h.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=8192,t=1,p=1$"))
+	valid, rehash, err := h.Verify("correct horse", encoded)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.False(t, rehash)
+	valid, _, err = h.Verify("wrong horse", encoded)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	_, rehash, err = argonHasher(16*1024).Verify("correct horse", encoded)
+	require.NoError(t, err)
+	assert.True(t, rehash)
+	_, _, err = h.Verify("correct horse", "$argon2id$v=19$broken")
+	assert.ErrorIs(t, err, ErrMalformedHash)
+}
+func TestAlgorithmMigrationNeedsRehash(t *testing.T) {
+	legacy, err := bcryptHasher(bcrypt.MinCost).Hash("correct horse")
+	require.NoError(t, err)
+	valid, rehash, err := argonHasher(8*1024).Verify("correct horse", legacy)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.True(t, rehash)
+}
+func TestPolicy(t *testing.T) {
+	p := &Policy{MinLength: 8, MaxLength: 72, MinUniqueChars: 4}
+	assert.ErrorIs(t, p.Check("short"), ErrTooShort)
+	assert.ErrorIs(t, p.Check(strings.Repeat("ab", 40)), ErrTooLong)
+	bcryptPolicy := &Policy{MinLength: 8, MaxLength: 72, MaxBytes: BcryptMaxBytes, MinUniqueChars: 4}
+	assert.NoError(t, p.Check(strings.Repeat("éàüö", 18)))
+	assert.ErrorIs(t, bcryptPolicy.Check(strings.Repeat("éàüö", 18)), ErrTooLong)
+	assert.ErrorIs(t, p.Check("aaaaaaaaaa"), ErrTooPredictable)
+	assert.ErrorIs(t, p.Check("my-john.doe-2024", "john.doe@example.com"), ErrPersonalInfo)
+	assert.NoError(t, p.Check("tangerine-bicycle-42", "john.doe@example.com", "John"))
+}
+func TestBreachedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# common passwords\npassword123\n" + sha1Hex("letmein-please") + ":3120\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	p := &Policy{MinLength: 8, MinUniqueChars: 4}
+	require.NoError(t, p.LoadBreachedList(path))
+	assert.ErrorIs(t, p.Check("password123"), ErrBreached)
+	assert.ErrorIs(t, p.Check("letmein-please"), ErrBreached)
+	assert.NoError(t, p.Check("tangerine-bicycle-42"))
+}
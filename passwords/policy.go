@@ -0,0 +1,107 @@
+package passwords
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+	"ecommerce/config"
+)
+var (
+	ErrTooShort       = errors.New("password is too short")
+	ErrTooLong        = errors.New("password is too long")
+	ErrTooPredictable = errors.New("password is too predictable")
+	ErrPersonalInfo   = errors.New("password must not contain your name or email")
+	ErrBreached       = errors.New("password has appeared in a data breach, choose another one")
+)
+type Policy struct {
+	MinLength      int
+	MaxLength      int
+	MaxBytes       int
+	MinUniqueChars int
+	breached       map[string]struct{}
+}
+func PolicyFromEnv() *Policy {
+	p := &Policy{
+		MinLength:      config.Int("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:      config.Int("PASSWORD_MAX_LENGTH", 72),
+		MinUniqueChars: 4,
+	}
+	if config.String("PASSWORD_ALGORITHM", AlgorithmBcrypt) == AlgorithmBcrypt {
+		p.MaxBytes = BcryptMaxBytes
+	}
+	if path := config.String("BREACHED_PASSWORDS_FILE", ""); path != "" {
+		if err := p.LoadBreachedList(path); err != nil {
+			log.Printf("could not load breached password list %s: %v", path, err)
+		}
+	}
+	return p
+}
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+func (p *Policy) LoadBreachedList(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry, _, _ := strings.Cut(line, ":")
+		if len(entry) == 40 {
+			if _, err := hex.DecodeString(entry); err == nil {
+				breached[strings.ToUpper(entry)] = struct{}{}
+				continue
+			}
+		}
+		breached[sha1Hex(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	p.breached = breached
+	return nil
+}
+func (p *Policy) Check(password string, personal ...string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return ErrTooShort
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return ErrTooLong
+	}
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		return ErrTooLong
+	}
+	unique := make(map[rune]struct{})
+	for _, r := range password {
+		unique[r] = struct{}{}
+	}
+	if len(unique) < p.MinUniqueChars {
+		return ErrTooPredictable
+	}
+	lowered := strings.ToLower(password)
+	for _, info := range personal {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if local, _, found := strings.Cut(info, "@"); found {
+			info = local
+		}
+		if len(info) >= 3 && strings.Contains(lowered, info) {
+			return ErrPersonalInfo
+		}
+	}
+	if _, found := p.breached[sha1Hex(password)]; found {
+		return ErrBreached
+	}
+	return nil
+}
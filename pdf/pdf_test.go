@@ -0,0 +1,41 @@
+package pdf
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+func TestDocumentStructure(t *testing.T) {
+	doc := New("Invoice (test)")
+	page := doc.AddPage()
+	page.Text(50, 800, 12, true, "Total: 12.50 €")
+	page.Line(50, 790, 545, 790, 0.5)
+	doc.AddPage().TextRight(545, 800, 10, false, `a\b (c)`)
+	out, err := doc.Bytes()
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), "/Count 2")
+	assert.Contains(t, string(out), "(Total: 12.50 \x80) Tj")
+	assert.Contains(t, string(out), `(a\\b \(c\)) Tj`)
+	assert.Contains(t, string(out), `/Title (Invoice \(test\))`)
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	require.NotNil(t, match)
+	xref, err := strconv.Atoi(string(match[1]))
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n0 10\n")))
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out, -1)
+	require.Len(t, offsets, 9)
+	for i, offset := range offsets {
+		at, err := strconv.Atoi(string(offset[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(out[at:], []byte(strconv.Itoa(i+1)+" 0 obj")), "object %d", i+1)
+	}
+}
+func TestWidth(t *testing.T) {
+	assert.InDelta(t, 5.56*4, Width("1234", 10, false), 0.001)
+	assert.Greater(t, Width("Total", 10, true), Width("Total", 10, false))
+	assert.Equal(t, "?", string(encode("→")))
+}
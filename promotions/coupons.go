@@ -0,0 +1,116 @@
+package promotions
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"ecommerce/models"
+	"ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+const SourceCoupon = "coupon"
+var (
+	ErrCouponInactive      = errors.New("coupon is not active")
+	ErrCouponNotStarted    = errors.New("coupon is not valid yet")
+	ErrCouponExpired       = errors.New("coupon has expired")
+	ErrCouponUsedUp        = errors.New("coupon usage limit reached")
+	ErrCouponUserLimit     = errors.New("you have already used this coupon")
+	ErrCouponMinTotal      = errors.New("cart total is below the coupon minimum")
+	ErrCouponNotApplicable = errors.New("coupon does not apply to any item in the cart")
+)
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+func Eligible(coupon models.Coupon, line models.ProductUser, catalog map[primitive.ObjectID]models.Product) bool {
+	if len(coupon.Product_IDs) == 0 && len(coupon.Category_IDs) == 0 {
+		return true
+	}
+	for _, id := range coupon.Product_IDs {
+		if id == line.Product_ID {
+			return true
+		}
+	}
+	for _, category := range catalog[line.Product_ID].Category_IDs {
+		for _, id := range coupon.Category_IDs {
+			if id == category {
+				return true
+			}
+		}
+	}
+	return false
+}
+func CheckUsable(coupon models.Coupon, userUses int, now time.Time) error {
+	switch {
+	case !coupon.Active:
+		return ErrCouponInactive
+	case coupon.Starts_At != nil && now.Before(*coupon.Starts_At):
+		return ErrCouponNotStarted
+	case coupon.Ends_At != nil && !now.Before(*coupon.Ends_At):
+		return ErrCouponExpired
+	case coupon.Max_Uses != nil && coupon.Uses >= *coupon.Max_Uses:
+		return ErrCouponUsedUp
+	case coupon.Max_Uses_Per_User != nil && userUses >= *coupon.Max_Uses_Per_User:
+		return ErrCouponUserLimit
+	}
+	return nil
+}
+func Evaluate(coupon models.Coupon, lines []models.ProductUser, catalog map[primitive.ObjectID]models.Product, userUses int, now time.Time) (models.DiscountLine, error) {
+	discount := models.DiscountLine{Source: SourceCoupon, Code: coupon.Code}
+	if err := CheckUsable(coupon, userUses, now); err != nil {
+		return discount, err
+	}
+	total, eligible := money.Zero(""), money.Zero("")
+	for _, line := range lines {
+		var err error
+		if total, err = total.Add(line.Price); err != nil {
+			return discount, err
+		}
+		if Eligible(coupon, line, catalog) {
+			if eligible, err = eligible.Add(line.Price); err != nil {
+				return discount, err
+			}
+		}
+	}
+	discount.Amount = money.Zero(total.Currency)
+	if cmp, err := total.Cmp(coupon.Min_Total); err != nil || cmp < 0 {
+		return discount, ErrCouponMinTotal
+	}
+	if eligible.IsZero() {
+		return discount, ErrCouponNotApplicable
+	}
+	switch coupon.Type {
+	case models.CouponPercentage:
+		percent := int64(coupon.Value)
+		if percent > 100 {
+			percent = 100
+		}
+		amount, err := eligible.Percent(percent, money.RoundHalfEven)
+		if err != nil {
+			return discount, err
+		}
+		discount.Amount = amount
+		discount.Description = fmt.Sprintf("%d%% off", percent)
+	case models.CouponFixed:
+		if !eligible.Compatible(coupon.Amount) {
+			return discount, ErrCouponNotApplicable
+		}
+		discount.Amount = money.Min(coupon.Amount, eligible)
+		discount.Description = fmt.Sprintf("%s off", discount.Amount)
+	case models.CouponFreeShipping:
+		discount.Free_Shipping = true
+		discount.Description = "free shipping"
+	}
+	return discount, nil
+}
+func Apply(subtotal money.Money, discounts []models.DiscountLine) (total money.Money, discount money.Money, freeShipping bool, err error) {
+	discount = money.Zero(subtotal.Currency)
+	for _, line := range discounts {
+		if discount, err = discount.Add(line.Amount); err != nil {
+			return subtotal, discount, false, err
+		}
+		freeShipping = freeShipping || line.Free_Shipping
+	}
+	discount = money.Min(discount, subtotal)
+	total, err = subtotal.Sub(discount)
+	return total, discount, freeShipping, err
+}
@@ -0,0 +1,81 @@
+package promotions
+import (
+	"testing"
+	"time"
+	"ecommerce/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func TestEvaluatePercentageOnEligibleCategory(t *testing.T) {
+	shoes := primitive.NewObjectID()
+	hat := primitive.NewObjectID()
+	footwear := primitive.NewObjectID()
+	catalog := map[primitive.ObjectID]models.Product{
+		shoes: {Product_ID: shoes, Category_IDs: []primitive.ObjectID{footwear}},
+		hat:   {Product_ID: hat},
+	}
+	lines := []models.ProductUser{{Product_ID: shoes, Price: usd(200)}, {Product_ID: hat, Price: usd(50)}}
+	coupon := models.Coupon{Code: "FEET10", Type: models.CouponPercentage, Value: 10, Active: true, Category_IDs: []primitive.ObjectID{footwear}}
+	discount, err := Evaluate(coupon, lines, catalog, 0, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, usd(20), discount.Amount)
+	assert.Equal(t, "FEET10", discount.Code)
+	coupon.Category_IDs = []primitive.ObjectID{primitive.NewObjectID()}
+	_, err = Evaluate(coupon, lines, catalog, 0, time.Now())
+	assert.ErrorIs(t, err, ErrCouponNotApplicable)
+}
+func TestEvaluateFixedAndFreeShipping(t *testing.T) {
+	lines := []models.ProductUser{{Product_ID: primitive.NewObjectID(), Price: usd(30)}}
+	fixed := models.Coupon{Type: models.CouponFixed, Amount: usd(50), Active: true}
+	discount, err := Evaluate(fixed, lines, nil, 0, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, usd(30), discount.Amount)
+	shipping := models.Coupon{Type: models.CouponFreeShipping, Active: true}
+	discount, err = Evaluate(shipping, lines, nil, 0, time.Now())
+	require.NoError(t, err)
+	assert.True(t, discount.Free_Shipping)
+	assert.True(t, discount.Amount.IsZero())
+}
+func TestEvaluateRules(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	one := 1
+	lines := []models.ProductUser{{Product_ID: primitive.NewObjectID(), Price: usd(100)}}
+	base := models.Coupon{Type: models.CouponFixed, Amount: usd(10), Active: true}
+	cases := []struct {
+		name     string
+		mutate   func(*models.Coupon)
+		userUses int
+		expected error
+	}{
+		{"inactive", func(c *models.Coupon) { c.Active = false }, 0, ErrCouponInactive},
+		{"not started", func(c *models.Coupon) { c.Starts_At = &future }, 0, ErrCouponNotStarted},
+		{"expired", func(c *models.Coupon) { c.Ends_At = &past }, 0, ErrCouponExpired},
+		{"global limit", func(c *models.Coupon) { c.Max_Uses = &one; c.Uses = 1 }, 0, ErrCouponUsedUp},
+		{"per user limit", func(c *models.Coupon) { c.Max_Uses_Per_User = &one }, 1, ErrCouponUserLimit},
+		{"minimum total", func(c *models.Coupon) { c.Min_Total = usd(101) }, 0, ErrCouponMinTotal},
+		{"valid", func(c *models.Coupon) { c.Starts_At = &past; c.Ends_At = &future }, 0, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			coupon := base
+			tc.mutate(&coupon)
+			_, err := Evaluate(coupon, lines, nil, tc.userUses, now)
+			if tc.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+func TestApply(t *testing.T) {
+	total, discount, free, err := Apply(usd(100), []models.DiscountLine{{Amount: usd(30)}, {Amount: usd(90), Free_Shipping: true}})
+	require.NoError(t, err)
+	assert.Equal(t, usd(0), total)
+	assert.Equal(t, usd(100), discount)
+	assert.True(t, free)
+	assert.Equal(t, "SAVE10", NormalizeCode(" save10 "))
+}
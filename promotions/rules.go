@@ -0,0 +1,229 @@
+package promotions
+import (
+	"fmt"
+	"sort"
+	"time"
+	"ecommerce/models"
+	"ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+const SourceRule = "promotion"
+func SortRules(rules []models.PromotionRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		if !rules[i].Created_At.Equal(rules[j].Created_At) {
+			return rules[i].Created_At.Before(rules[j].Created_At)
+		}
+		return rules[i].ID.Hex() < rules[j].ID.Hex()
+	})
+}
+func ruleActive(rule models.PromotionRule, now time.Time) string {
+	switch {
+	case !rule.Active:
+		return "rule is inactive"
+	case rule.Starts_At != nil && now.Before(*rule.Starts_At):
+		return "rule has not started"
+	case rule.Ends_At != nil && !now.Before(*rule.Ends_At):
+		return "rule has ended"
+	}
+	return ""
+}
+func inRule(rule models.PromotionRule, productID primitive.ObjectID) bool {
+	if len(rule.Product_IDs) == 0 {
+		return true
+	}
+	for _, id := range rule.Product_IDs {
+		if id == productID {
+			return true
+		}
+	}
+	return false
+}
+func total(amounts []money.Money) (money.Money, error) {
+	sum := money.Zero("")
+	for _, amount := range amounts {
+		var err error
+		if sum, err = sum.Add(amount); err != nil {
+			return sum, err
+		}
+	}
+	return sum, nil
+}
+func byPrice(lines []models.ProductUser, eligible func(models.ProductUser) bool) []int {
+	indexes := make([]int, 0, len(lines))
+	for i, line := range lines {
+		if eligible(line) {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool { return lines[indexes[j]].Price.LessThan(lines[indexes[i]].Price) })
+	return indexes
+}
+func allocate(amount money.Money, lines []models.ProductUser, indexes []int) []money.Money {
+	shares := make([]money.Money, len(lines))
+	for _, i := range indexes {
+		if amount.Amount <= 0 {
+			break
+		}
+		share := money.Min(amount, lines[i].Price)
+		if share.Amount <= 0 {
+			continue
+		}
+		shares[i] = share
+		amount, _ = amount.Sub(share)
+	}
+	return shares
+}
+func buyXGetY(rule models.PromotionRule, lines []models.ProductUser) ([]money.Money, string, error) {
+	shares := make([]money.Money, len(lines))
+	if rule.Buy_Quantity < 1 || rule.Get_Quantity < 1 {
+		return shares, "rule needs buy_quantity and get_quantity", nil
+	}
+	eligible := byPrice(lines, func(line models.ProductUser) bool { return inRule(rule, line.Product_ID) })
+	group := rule.Buy_Quantity + rule.Get_Quantity
+	if len(eligible) < group {
+		return shares, fmt.Sprintf("needs %d eligible items, cart has %d", group, len(eligible)), nil
+	}
+	for start := 0; start+group <= len(eligible); start += group {
+		for _, i := range eligible[start+rule.Buy_Quantity : start+group] {
+			shares[i] = lines[i].Price
+		}
+	}
+	return shares, fmt.Sprintf("buy %d get %d free applied %d times", rule.Buy_Quantity, rule.Get_Quantity, len(eligible)/group), nil
+}
+func bundle(rule models.PromotionRule, lines []models.ProductUser) ([]money.Money, string, error) {
+	shares := make([]money.Money, len(lines))
+	if len(rule.Product_IDs) == 0 {
+		return shares, "bundle has no products", nil
+	}
+	counts := make(map[primitive.ObjectID]int)
+	prices := make(map[primitive.ObjectID]money.Money)
+	for _, line := range lines {
+		counts[line.Product_ID]++
+		if prices[line.Product_ID].LessThan(line.Price) {
+			prices[line.Product_ID] = line.Price
+		}
+	}
+	sets := -1
+	regular := money.Zero("")
+	for _, id := range rule.Product_IDs {
+		if sets == -1 || counts[id] < sets {
+			sets = counts[id]
+		}
+		var err error
+		if regular, err = regular.Add(prices[id]); err != nil {
+			return shares, "", err
+		}
+	}
+	if sets == 0 {
+		return shares, "cart does not contain the complete bundle", nil
+	}
+	if !rule.Bundle_Price.LessThan(regular) {
+		return shares, "bundle price is not lower than the regular price", nil
+	}
+	saving, err := regular.Sub(rule.Bundle_Price)
+	if err != nil {
+		return shares, "", err
+	}
+	if saving, err = saving.Mul(int64(sets)); err != nil {
+		return shares, "", err
+	}
+	eligible := byPrice(lines, func(line models.ProductUser) bool { return inRule(rule, line.Product_ID) })
+	return allocate(saving, lines, eligible), fmt.Sprintf("bundle priced at %s applied %d times", rule.Bundle_Price, sets), nil
+}
+func tiered(rule models.PromotionRule, lines []models.ProductUser) ([]money.Money, string, error) {
+	shares := make([]money.Money, len(lines))
+	prices := make([]money.Money, len(lines))
+	for i, line := range lines {
+		prices[i] = line.Price
+	}
+	base, err := total(prices)
+	if err != nil {
+		return shares, "", err
+	}
+	var best *models.PromotionTier
+	for i, tier := range rule.Tiers {
+		if tier.Percent <= 0 || base.LessThan(tier.Min_Total) || !base.Compatible(tier.Min_Total) {
+			continue
+		}
+		if best == nil || best.Min_Total.LessThan(tier.Min_Total) {
+			best = &rule.Tiers[i]
+		}
+	}
+	if best == nil {
+		return shares, "cart total is below every tier", nil
+	}
+	amount, err := base.Percent(int64(best.Percent), money.RoundHalfEven)
+	if err != nil {
+		return shares, "", err
+	}
+	eligible := byPrice(lines, func(models.ProductUser) bool { return true })
+	return allocate(amount, lines, eligible), fmt.Sprintf("%d%% off for spending at least %s", best.Percent, best.Min_Total), nil
+}
+func EvaluateRules(rules []models.PromotionRule, lines []models.ProductUser, now time.Time) ([]models.DiscountLine, []models.RuleResult, error) {
+	sorted := append([]models.PromotionRule(nil), rules...)
+	SortRules(sorted)
+	subtotal := money.Zero("")
+	for _, line := range lines {
+		var err error
+		if subtotal, err = subtotal.Add(line.Price); err != nil {
+			return nil, nil, err
+		}
+	}
+	remaining := append([]models.ProductUser(nil), lines...)
+	discounts := make([]models.DiscountLine, 0)
+	results := make([]models.RuleResult, 0, len(sorted))
+	blocked := false
+	for _, rule := range sorted {
+		result := models.RuleResult{Rule_ID: rule.ID, Name: rule.Name, Type: rule.Type, Amount: money.Zero(subtotal.Currency)}
+		if reason := ruleActive(rule, now); reason != "" {
+			result.Reason = reason
+			results = append(results, result)
+			continue
+		}
+		if blocked {
+			result.Reason = "skipped because an exclusive rule already applied"
+			results = append(results, result)
+			continue
+		}
+		if rule.Exclusive && len(discounts) > 0 {
+			result.Reason = "exclusive rule skipped because other rules already applied"
+			results = append(results, result)
+			continue
+		}
+		var shares []money.Money
+		var err error
+		switch rule.Type {
+		case models.RuleBuyXGetY:
+			shares, result.Reason, err = buyXGetY(rule, remaining)
+		case models.RuleBundle:
+			shares, result.Reason, err = bundle(rule, remaining)
+		case models.RuleTiered:
+			shares, result.Reason, err = tiered(rule, remaining)
+		default:
+			result.Reason = "unknown rule type"
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		amount, err := total(shares)
+		if err != nil {
+			return nil, nil, err
+		}
+		if amount.Amount > 0 {
+			for i, share := range shares {
+				if remaining[i].Price, err = remaining[i].Price.Sub(share); err != nil {
+					return nil, nil, err
+				}
+			}
+			result.Fired = true
+			result.Amount = amount
+			blocked = rule.Exclusive
+			discounts = append(discounts, models.DiscountLine{Source: SourceRule, Code: rule.ID.Hex(), Description: rule.Name, Amount: amount})
+		}
+		results = append(results, result)
+	}
+	return discounts, results, nil
+}
@@ -0,0 +1,98 @@
+package promotions
+import (
+	"testing"
+	"time"
+	"ecommerce/models"
+	"ecommerce/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func usd(amount int64) money.Money {
+	return money.New(amount, "USD")
+}
+func line(id primitive.ObjectID, price int64) models.ProductUser {
+	return models.ProductUser{Product_ID: id, Price: usd(price)}
+}
+func TestBuyTwoGetOne(t *testing.T) {
+	socks := primitive.NewObjectID()
+	rule := models.PromotionRule{ID: primitive.NewObjectID(), Name: "3 for 2", Type: models.RuleBuyXGetY, Active: true, Buy_Quantity: 2, Get_Quantity: 1, Product_IDs: []primitive.ObjectID{socks}}
+	lines := []models.ProductUser{line(socks, 10), line(socks, 12), line(socks, 8), line(socks, 10), line(primitive.NewObjectID(), 100)}
+	discounts, results, err := EvaluateRules([]models.PromotionRule{rule}, lines, time.Now())
+	require.NoError(t, err)
+	require.Len(t, discounts, 1)
+	assert.Equal(t, usd(10), discounts[0].Amount)
+	assert.True(t, results[0].Fired)
+}
+func TestBundle(t *testing.T) {
+	phone := primitive.NewObjectID()
+	case_ := primitive.NewObjectID()
+	rule := models.PromotionRule{ID: primitive.NewObjectID(), Name: "phone + case", Type: models.RuleBundle, Active: true, Product_IDs: []primitive.ObjectID{phone, case_}, Bundle_Price: usd(500)}
+	discounts, _, err := EvaluateRules([]models.PromotionRule{rule}, []models.ProductUser{line(phone, 480), line(case_, 40), line(phone, 480)}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, discounts, 1)
+	assert.Equal(t, usd(20), discounts[0].Amount)
+	discounts, results, err := EvaluateRules([]models.PromotionRule{rule}, []models.ProductUser{line(phone, 480)}, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, discounts)
+	assert.False(t, results[0].Fired)
+}
+func TestTieredAndStacking(t *testing.T) {
+	now := time.Now()
+	item := primitive.NewObjectID()
+	lines := []models.ProductUser{line(item, 100), line(item, 100), line(item, 100)}
+	tiers := models.PromotionRule{ID: primitive.NewObjectID(), Name: "spend more", Type: models.RuleTiered, Active: true, Priority: 1,
+		Tiers: []models.PromotionTier{{Min_Total: usd(100), Percent: 5}, {Min_Total: usd(200), Percent: 10}, {Min_Total: usd(1000), Percent: 50}}}
+	threeForTwo := models.PromotionRule{ID: primitive.NewObjectID(), Name: "3 for 2", Type: models.RuleBuyXGetY, Active: true, Priority: 10, Buy_Quantity: 2, Get_Quantity: 1}
+	discounts, results, err := EvaluateRules([]models.PromotionRule{tiers, threeForTwo}, lines, now)
+	require.NoError(t, err)
+	require.Len(t, discounts, 2)
+	assert.Equal(t, "3 for 2", results[0].Name)
+	assert.Equal(t, usd(100), discounts[0].Amount)
+	assert.Equal(t, usd(20), discounts[1].Amount)
+	threeForTwo.Exclusive = true
+	discounts, results, err = EvaluateRules([]models.PromotionRule{tiers, threeForTwo}, lines, now)
+	require.NoError(t, err)
+	require.Len(t, discounts, 1)
+	assert.False(t, results[1].Fired)
+	tiers.Priority = 20
+	discounts, _, err = EvaluateRules([]models.PromotionRule{tiers, threeForTwo}, lines, now)
+	require.NoError(t, err)
+	require.Len(t, discounts, 1)
+	assert.Equal(t, usd(30), discounts[0].Amount)
+}
+func TestStackedRulesDiscountRemainingAmount(t *testing.T) {
+	now := time.Now()
+	shirt := primitive.NewObjectID()
+	hat := primitive.NewObjectID()
+	lines := []models.ProductUser{line(shirt, 150), line(hat, 50)}
+	tenOff := models.PromotionRule{ID: primitive.NewObjectID(), Name: "10% off", Type: models.RuleTiered, Active: true, Priority: 2, Tiers: []models.PromotionTier{{Min_Total: usd(100), Percent: 10}}}
+	twentyOff := models.PromotionRule{ID: primitive.NewObjectID(), Name: "20% off", Type: models.RuleTiered, Active: true, Priority: 1, Tiers: []models.PromotionTier{{Min_Total: usd(100), Percent: 20}}}
+	discounts, _, err := EvaluateRules([]models.PromotionRule{tenOff, twentyOff}, lines, now)
+	require.NoError(t, err)
+	require.Len(t, discounts, 2)
+	assert.Equal(t, usd(20), discounts[0].Amount)
+	assert.Equal(t, usd(36), discounts[1].Amount)
+	socks := primitive.NewObjectID()
+	lines = []models.ProductUser{line(socks, 10), line(socks, 10), line(socks, 10)}
+	threeForTwo := models.PromotionRule{ID: primitive.NewObjectID(), Name: "3 for 2", Type: models.RuleBuyXGetY, Active: true, Priority: 2, Buy_Quantity: 2, Get_Quantity: 1}
+	sameAgain := models.PromotionRule{ID: primitive.NewObjectID(), Name: "also 3 for 2", Type: models.RuleBuyXGetY, Active: true, Priority: 1, Buy_Quantity: 2, Get_Quantity: 1}
+	discounts, results, err := EvaluateRules([]models.PromotionRule{threeForTwo, sameAgain}, lines, now)
+	require.NoError(t, err)
+	require.Len(t, discounts, 1)
+	assert.Equal(t, usd(10), discounts[0].Amount)
+	assert.False(t, results[1].Fired)
+}
+func TestInactiveRulesAreExplained(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	rules := []models.PromotionRule{
+		{ID: primitive.NewObjectID(), Name: "off", Type: models.RuleTiered, Active: false},
+		{ID: primitive.NewObjectID(), Name: "over", Type: models.RuleTiered, Active: true, Ends_At: &past},
+	}
+	discounts, results, err := EvaluateRules(rules, []models.ProductUser{line(primitive.NewObjectID(), 10)}, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, discounts)
+	require.Len(t, results, 2)
+	assert.Equal(t, "rule is inactive", results[0].Reason)
+	assert.Equal(t, "rule has ended", results[1].Reason)
+}
@@ -1,12 +1,46 @@
 package routes
 import (
 	"ecommerce/controllers"
+	"ecommerce/middleware"
 	"github.com/gin-gonic/gin"
 )
 func UserRoutes(incomingRoutes *gin.Engine) {
 	incomingRoutes.POST("/users/signup", controllers.SignUp())
 	incomingRoutes.POST("/users/login", controllers.Login())
+	incomingRoutes.POST("/users/login/2fa", controllers.LoginTwoFactor())
+	incomingRoutes.POST("/users/token/refresh", controllers.RefreshSession())
+	incomingRoutes.POST("/users/password/forgot", controllers.ForgotPassword())
+	incomingRoutes.POST("/users/password/reset", controllers.ResetPassword())
+	incomingRoutes.GET("/users/verify", controllers.VerifyEmail())
 	incomingRoutes.POST("/admin/addproduct", controllers.ProductViewerAdmin())
 	incomingRoutes.GET("/users/productview", controllers.SearchProduct())
 	incomingRoutes.GET("/users/search", controllers.SearchProductByQuery())
+	incomingRoutes.GET("/.well-known/jwks.json", controllers.JWKS())
+	incomingRoutes.GET("/categories", controllers.ListCategories())
+	incomingRoutes.GET("/categories/:slug/products", controllers.ListCategoryProducts())
+	AdminRoutes(incomingRoutes.Group("/admin", middleware.Admin()))
+}
+func AdminRoutes(adminRoutes *gin.RouterGroup) {
+	adminRoutes.GET("/audit-log", controllers.ListAuditEvents())
+	adminRoutes.POST("/coupons", controllers.CreateCoupon())
+	adminRoutes.GET("/coupons", controllers.ListCoupons())
+	adminRoutes.POST("/promotions", controllers.CreatePromotionRule())
+	adminRoutes.GET("/promotions", controllers.ListPromotionRules())
+	adminRoutes.PUT("/promotions/:id", controllers.UpdatePromotionRule())
+	adminRoutes.DELETE("/promotions/:id", controllers.DeletePromotionRule())
+	adminRoutes.POST("/exchange-rates", controllers.CreateExchangeRate())
+	adminRoutes.GET("/exchange-rates", controllers.ListExchangeRates())
+	adminRoutes.POST("/tax-rules", controllers.CreateTaxRule())
+	adminRoutes.GET("/tax-rules", controllers.ListTaxRules())
+	adminRoutes.DELETE("/tax-rules/:id", controllers.DeleteTaxRule())
+	adminRoutes.POST("/shipping-methods", controllers.CreateShippingMethod())
+	adminRoutes.GET("/shipping-methods", controllers.ListShippingMethods())
+	adminRoutes.DELETE("/shipping-methods/:id", controllers.DeleteShippingMethod())
+	adminRoutes.POST("/orders/:id/shipments", controllers.CreateShipment())
+	adminRoutes.PATCH("/shipments/:id", controllers.UpdateShipmentStatus())
+	adminRoutes.POST("/orders/:id/credit-notes", controllers.CreateCreditNote())
+	adminRoutes.POST("/categories", controllers.CreateCategory())
+	adminRoutes.PATCH("/categories/:id/move", controllers.MoveCategory())
+	adminRoutes.PUT("/products/:id/categories", controllers.SetProductCategories())
+	adminRoutes.PUT("/products/:id/variants", controllers.SetProductVariants())
 }
\ No newline at end of file
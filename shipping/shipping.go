@@ -0,0 +1,145 @@
+package shipping
+import (
+	"errors"
+	"sort"
+	"strings"
+	"ecommerce/config"
+	"ecommerce/models"
+	"ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+var (
+	ErrUnavailable   = errors.New("shipping method is not available for this cart")
+	ErrInvalidMethod = errors.New("shipping method is misconfigured")
+)
+type Parcel struct {
+	Country       string
+	Subtotal      money.Money
+	Weight_Grams  int64
+	Free_Shipping bool
+}
+func volumetricDivisor() int64 {
+	divisor := int64(config.Int("SHIPPING_VOLUMETRIC_DIVISOR", 5000))
+	if divisor <= 0 {
+		return 5000
+	}
+	return divisor
+}
+func BillableWeight(product models.Product) int64 {
+	var weight int64
+	if product.Weight_Grams != nil {
+		weight = *product.Weight_Grams
+	}
+	if product.Dimensions != nil {
+		dimensions := product.Dimensions
+		volumetric := dimensions.Length_Mm * dimensions.Width_Mm * dimensions.Height_Mm / volumetricDivisor()
+		if volumetric > weight {
+			weight = volumetric
+		}
+	}
+	return weight
+}
+func NewParcel(lines []models.ProductUser, catalog map[primitive.ObjectID]models.Product, country string, subtotal money.Money, freeShipping bool) Parcel {
+	parcel := Parcel{Country: strings.ToUpper(strings.TrimSpace(country)), Subtotal: subtotal, Free_Shipping: freeShipping}
+	for _, line := range lines {
+		parcel.Weight_Grams += BillableWeight(catalog[line.Product_ID])
+	}
+	return parcel
+}
+func Validate(method models.ShippingMethod) error {
+	switch method.Type {
+	case models.ShippingFlat:
+	case models.ShippingFreeOver:
+		if method.Free_Over.IsZero() {
+			return ErrInvalidMethod
+		}
+	case models.ShippingWeight:
+		if len(method.Weight_Tiers) == 0 {
+			return ErrInvalidMethod
+		}
+		for i, tier := range method.Weight_Tiers {
+			if tier.Rate.IsNegative() || (i > 0 && tier.Up_To_Grams <= method.Weight_Tiers[i-1].Up_To_Grams) {
+				return ErrInvalidMethod
+			}
+		}
+	default:
+		return ErrInvalidMethod
+	}
+	if method.Rate.IsNegative() || method.Free_Over.IsNegative() {
+		return ErrInvalidMethod
+	}
+	return nil
+}
+func servesCountry(method models.ShippingMethod, country string) bool {
+	if len(method.Countries) == 0 {
+		return true
+	}
+	for _, code := range method.Countries {
+		if strings.EqualFold(code, country) {
+			return true
+		}
+	}
+	return false
+}
+func Rate(method models.ShippingMethod, parcel Parcel) (money.Money, bool, error) {
+	if !method.Active || !servesCountry(method, parcel.Country) {
+		return money.Money{}, false, nil
+	}
+	rate := method.Rate
+	if method.Type == models.ShippingWeight {
+		found := false
+		for _, tier := range method.Weight_Tiers {
+			if parcel.Weight_Grams <= tier.Up_To_Grams {
+				rate = tier.Rate
+				found = true
+				break
+			}
+		}
+		if !found {
+			return money.Money{}, false, nil
+		}
+	}
+	if parcel.Free_Shipping {
+		return money.Zero(rate.Currency), true, nil
+	}
+	if !method.Free_Over.IsZero() {
+		cmp, err := parcel.Subtotal.Cmp(method.Free_Over)
+		if err != nil {
+			return rate, false, err
+		}
+		if cmp >= 0 {
+			return money.Zero(rate.Currency), true, nil
+		}
+	}
+	return rate, true, nil
+}
+func Quote(methods []models.ShippingMethod, parcel Parcel) ([]models.ShippingQuote, error) {
+	quotes := make([]models.ShippingQuote, 0, len(methods))
+	for _, method := range methods {
+		cost, ok, err := Rate(method, parcel)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			quotes = append(quotes, models.ShippingQuote{Code: method.Code, Name: method.Name, Cost: cost, Weight_Grams: parcel.Weight_Grams})
+		}
+	}
+	sort.SliceStable(quotes, func(i, j int) bool {
+		if quotes[i].Cost.Amount != quotes[j].Cost.Amount {
+			return quotes[i].Cost.Amount < quotes[j].Cost.Amount
+		}
+		return quotes[i].Code < quotes[j].Code
+	})
+	return quotes, nil
+}
+func Select(quotes []models.ShippingQuote, code string) (models.ShippingQuote, error) {
+	if code == "" && len(quotes) > 0 {
+		return quotes[0], nil
+	}
+	for _, quote := range quotes {
+		if quote.Code == code {
+			return quote, nil
+		}
+	}
+	return models.ShippingQuote{}, ErrUnavailable
+}
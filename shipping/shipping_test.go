@@ -0,0 +1,83 @@
+package shipping
+import (
+	"testing"
+	"ecommerce/models"
+	"ecommerce/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func usd(amount int64) money.Money {
+	return money.New(amount, "USD")
+}
+func methods() []models.ShippingMethod {
+	return []models.ShippingMethod{
+		{Code: "standard", Name: "Standard", Type: models.ShippingFlat, Rate: usd(500), Free_Over: usd(5000), Active: true},
+		{Code: "express", Name: "Express", Type: models.ShippingWeight, Active: true, Weight_Tiers: []models.WeightTier{
+			{Up_To_Grams: 1000, Rate: usd(1200)},
+			{Up_To_Grams: 5000, Rate: usd(2500)},
+		}},
+		{Code: "local", Name: "Local courier", Type: models.ShippingFlat, Rate: usd(300), Countries: []string{"US"}, Active: true},
+		{Code: "retired", Name: "Retired", Type: models.ShippingFlat, Rate: usd(100)},
+	}
+}
+func TestBillableWeightUsesVolumetricWeight(t *testing.T) {
+	light := int64(200)
+	heavy := int64(9000)
+	box := &models.Dimensions{Length_Mm: 400, Width_Mm: 300, Height_Mm: 200}
+	assert.Equal(t, int64(4800), BillableWeight(models.Product{Weight_Grams: &light, Dimensions: box}))
+	assert.Equal(t, int64(9000), BillableWeight(models.Product{Weight_Grams: &heavy, Dimensions: box}))
+	assert.Equal(t, int64(0), BillableWeight(models.Product{}))
+}
+func TestQuoteSortsAvailableMethods(t *testing.T) {
+	id := primitive.NewObjectID()
+	weight := int64(800)
+	catalog := map[primitive.ObjectID]models.Product{id: {Product_ID: id, Weight_Grams: &weight}}
+	parcel := NewParcel([]models.ProductUser{{Product_ID: id}, {Product_ID: id}}, catalog, "us", usd(2000), false)
+	assert.Equal(t, int64(1600), parcel.Weight_Grams)
+	quotes, err := Quote(methods(), parcel)
+	require.NoError(t, err)
+	require.Len(t, quotes, 3)
+	assert.Equal(t, []string{"local", "standard", "express"}, []string{quotes[0].Code, quotes[1].Code, quotes[2].Code})
+	assert.Equal(t, usd(2500), quotes[2].Cost)
+	parcel.Country = "DE"
+	parcel.Weight_Grams = 6000
+	quotes, err = Quote(methods(), parcel)
+	require.NoError(t, err)
+	require.Len(t, quotes, 1)
+	assert.Equal(t, "standard", quotes[0].Code)
+}
+func TestRateHonorsThresholdAndFreeShipping(t *testing.T) {
+	standard := methods()[0]
+	cost, ok, err := Rate(standard, Parcel{Subtotal: usd(5000)})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, usd(0), cost)
+	express := methods()[1]
+	cost, ok, err = Rate(express, Parcel{Subtotal: usd(100), Weight_Grams: 10, Free_Shipping: true})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, usd(0), cost)
+}
+func TestSelect(t *testing.T) {
+	quotes := []models.ShippingQuote{{Code: "local", Cost: usd(300)}, {Code: "express", Cost: usd(1200)}}
+	quote, err := Select(quotes, "")
+	require.NoError(t, err)
+	assert.Equal(t, "local", quote.Code)
+	quote, err = Select(quotes, "express")
+	require.NoError(t, err)
+	assert.Equal(t, usd(1200), quote.Cost)
+	_, err = Select(quotes, "pigeon")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	_, err = Select(nil, "")
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+func TestValidate(t *testing.T) {
+	for _, method := range methods() {
+		assert.NoError(t, Validate(method), method.Code)
+	}
+	assert.ErrorIs(t, Validate(models.ShippingMethod{Type: models.ShippingFreeOver, Rate: usd(100)}), ErrInvalidMethod)
+	unordered := models.ShippingMethod{Type: models.ShippingWeight, Weight_Tiers: []models.WeightTier{{Up_To_Grams: 500}, {Up_To_Grams: 100}}}
+	assert.ErrorIs(t, Validate(unordered), ErrInvalidMethod)
+	assert.ErrorIs(t, Validate(models.ShippingMethod{Type: "teleport"}), ErrInvalidMethod)
+}
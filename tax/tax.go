@@ -0,0 +1,182 @@
+package tax
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"ecommerce/config"
+	"ecommerce/models"
+	"ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+var ErrInvalidRate = errors.New("tax rate must be a percentage between 0 and 100")
+type Jurisdiction struct {
+	Country string
+	Region  string
+}
+func NewJurisdiction(country, region string) Jurisdiction {
+	return Jurisdiction{Country: strings.ToUpper(strings.TrimSpace(country)), Region: strings.ToUpper(strings.TrimSpace(region))}
+}
+func FromAddress(address models.Address) Jurisdiction {
+	var country, region string
+	if address.Country != nil {
+		country = *address.Country
+	}
+	if address.Region != nil {
+		region = *address.Region
+	}
+	if strings.TrimSpace(country) == "" {
+		return NewJurisdiction(config.String("TAX_DEFAULT_COUNTRY", ""), "")
+	}
+	return NewJurisdiction(country, region)
+}
+func (j Jurisdiction) IsZero() bool {
+	return j.Country == ""
+}
+func (j Jurisdiction) String() string {
+	if j.Region == "" {
+		return j.Country
+	}
+	return j.Country + "-" + j.Region
+}
+func ParseRate(rate string) (*big.Rat, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || value.Sign() < 0 || value.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, ErrInvalidRate
+	}
+	return value, nil
+}
+func Match(rules []models.TaxRule, jurisdiction Jurisdiction, category string) (models.TaxRule, bool) {
+	var selected models.TaxRule
+	best := -1
+	if jurisdiction.IsZero() || category == models.TaxCategoryExempt {
+		return selected, false
+	}
+	for _, rule := range rules {
+		if !strings.EqualFold(rule.Country, jurisdiction.Country) {
+			continue
+		}
+		score := 0
+		if rule.Region != "" {
+			if !strings.EqualFold(rule.Region, jurisdiction.Region) {
+				continue
+			}
+			score += 2
+		}
+		if rule.Category != "" {
+			if rule.Category != category {
+				continue
+			}
+			score++
+		}
+		if score > best || (score == best && rule.Created_At.After(selected.Created_At)) {
+			selected = rule
+			best = score
+		}
+	}
+	return selected, best >= 0
+}
+func Allocate(amounts []money.Money, discount money.Money) ([]money.Money, error) {
+	allocated := make([]money.Money, len(amounts))
+	copy(allocated, amounts)
+	if discount.IsZero() || len(amounts) == 0 {
+		return allocated, nil
+	}
+	total, err := money.Sum(discount.Currency, amounts...)
+	if err != nil {
+		return nil, err
+	}
+	if total.IsZero() {
+		return allocated, nil
+	}
+	discount = money.Min(discount, total)
+	remaining := discount
+	for i, amount := range amounts {
+		share, err := discount.MulRat(amount.Amount, total.Amount, money.RoundDown)
+		if err != nil {
+			return nil, err
+		}
+		if allocated[i], err = amount.Sub(share); err != nil {
+			return nil, err
+		}
+		if remaining, err = remaining.Sub(share); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; remaining.Amount > 0 && i < len(allocated); i++ {
+		if allocated[i].Amount > 0 {
+			allocated[i].Amount--
+			remaining.Amount--
+		}
+	}
+	return allocated, nil
+}
+type Line struct {
+	Product_ID primitive.ObjectID
+	Category   string
+	Amount     money.Money
+}
+type Breakdown struct {
+	Lines     []models.TaxLine
+	Total     money.Money
+	Exclusive money.Money
+}
+func Calculate(rules []models.TaxRule, jurisdiction Jurisdiction, lines []Line, discount money.Money) (Breakdown, error) {
+	amounts := make([]money.Money, 0, len(lines))
+	for _, line := range lines {
+		amounts = append(amounts, line.Amount)
+	}
+	currency := discount.Currency
+	if len(amounts) > 0 {
+		currency = amounts[0].Currency
+	}
+	breakdown := Breakdown{Lines: make([]models.TaxLine, 0, len(lines)), Total: money.Zero(currency)}
+	taxable, err := Allocate(amounts, discount)
+	if err != nil {
+		return breakdown, err
+	}
+	for i, line := range lines {
+		taxLine := models.TaxLine{Product_ID: line.Product_ID, Category: line.Category, Rate: "0", Taxable: taxable[i], Tax: money.Zero(taxable[i].Currency)}
+		if rule, ok := Match(rules, jurisdiction, line.Category); ok {
+			taxLine.Rule = rule.Name
+			taxLine.Rate = rule.Rate
+			taxLine.Inclusive = rule.Inclusive
+			if taxLine.Tax, err = lineTax(taxable[i], rule.Rate, rule.Inclusive); err != nil {
+				return breakdown, err
+			}
+		}
+		if breakdown.Total, err = breakdown.Total.Add(taxLine.Tax); err != nil {
+			return breakdown, err
+		}
+		breakdown.Lines = append(breakdown.Lines, taxLine)
+	}
+	breakdown.Exclusive, err = Exclusive(breakdown.Lines, currency)
+	return breakdown, err
+}
+func lineTax(amount money.Money, rate string, inclusive bool) (money.Money, error) {
+	value, err := ParseRate(rate)
+	if err != nil {
+		return amount, err
+	}
+	numerator := value.Num()
+	denominator := new(big.Int).Mul(value.Denom(), big.NewInt(100))
+	if inclusive {
+		denominator.Add(denominator, numerator)
+	}
+	if !numerator.IsInt64() || !denominator.IsInt64() {
+		return amount, money.ErrOverflow
+	}
+	return amount.MulRat(numerator.Int64(), denominator.Int64(), money.RoundHalfEven)
+}
+func Exclusive(lines []models.TaxLine, currency string) (money.Money, error) {
+	exclusive := money.Zero(currency)
+	var err error
+	for _, line := range lines {
+		if line.Inclusive {
+			continue
+		}
+		if exclusive, err = exclusive.Add(line.Tax); err != nil {
+			return exclusive, err
+		}
+	}
+	return exclusive, nil
+}
@@ -0,0 +1,100 @@
+package tax
+import (
+	"testing"
+	"time"
+	"ecommerce/models"
+	"ecommerce/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func usd(amount int64) money.Money {
+	return money.New(amount, "USD")
+}
+func TestMatchPrefersMostSpecificRule(t *testing.T) {
+	now := time.Now()
+	rules := []models.TaxRule{
+		{Name: "US", Country: "US", Rate: "5"},
+		{Name: "US books", Country: "US", Category: "books", Rate: "2"},
+		{Name: "CA state", Country: "US", Region: "CA", Rate: "7.25"},
+		{Name: "CA state old", Country: "US", Region: "CA", Rate: "7", Created_At: now.Add(-time.Hour)},
+		{Name: "CA food", Country: "US", Region: "CA", Category: "food", Rate: "0"},
+		{Name: "DE", Country: "DE", Rate: "19", Inclusive: true},
+	}
+	rules[2].Created_At = now
+	cases := []struct {
+		jurisdiction Jurisdiction
+		category     string
+		want         string
+	}{
+		{NewJurisdiction("us", "ca"), "food", "CA food"},
+		{NewJurisdiction("US", "CA"), "books", "CA state"},
+		{NewJurisdiction("US", "NY"), "books", "US books"},
+		{NewJurisdiction("US", ""), "", "US"},
+		{NewJurisdiction("de", "BY"), "books", "DE"},
+	}
+	for _, tc := range cases {
+		rule, ok := Match(rules, tc.jurisdiction, tc.category)
+		require.True(t, ok, tc.jurisdiction.String())
+		assert.Equal(t, tc.want, rule.Name, tc.jurisdiction.String())
+	}
+	_, ok := Match(rules, NewJurisdiction("FR", ""), "")
+	assert.False(t, ok)
+	_, ok = Match(rules, NewJurisdiction("US", "CA"), models.TaxCategoryExempt)
+	assert.False(t, ok)
+}
+func TestAllocateSpreadsDiscountWithoutLosingCents(t *testing.T) {
+	allocated, err := Allocate([]money.Money{usd(1000), usd(1000), usd(1000)}, usd(100))
+	require.NoError(t, err)
+	assert.Equal(t, []money.Money{usd(966), usd(967), usd(967)}, allocated)
+	total, err := money.Sum("USD", allocated...)
+	require.NoError(t, err)
+	assert.Equal(t, usd(2900), total)
+	allocated, err = Allocate([]money.Money{usd(100)}, usd(500))
+	require.NoError(t, err)
+	assert.Equal(t, []money.Money{usd(0)}, allocated)
+}
+func TestCalculateExclusiveAndInclusive(t *testing.T) {
+	book := primitive.NewObjectID()
+	shirt := primitive.NewObjectID()
+	rules := []models.TaxRule{
+		{Name: "US", Country: "US", Rate: "10"},
+		{Name: "US books", Country: "US", Category: "books", Rate: "0"},
+	}
+	lines := []Line{
+		{Product_ID: book, Category: "books", Amount: usd(2000)},
+		{Product_ID: shirt, Category: "apparel", Amount: usd(1999)},
+	}
+	breakdown, err := Calculate(rules, NewJurisdiction("US", "OR"), lines, money.Zero("USD"))
+	require.NoError(t, err)
+	require.Len(t, breakdown.Lines, 2)
+	assert.Equal(t, usd(0), breakdown.Lines[0].Tax)
+	assert.Equal(t, "US books", breakdown.Lines[0].Rule)
+	assert.Equal(t, usd(200), breakdown.Lines[1].Tax)
+	assert.Equal(t, usd(200), breakdown.Total)
+	assert.Equal(t, usd(200), breakdown.Exclusive)
+	vat := []models.TaxRule{{Name: "DE", Country: "DE", Rate: "19", Inclusive: true}}
+	breakdown, err = Calculate(vat, NewJurisdiction("DE", ""), []Line{{Product_ID: shirt, Amount: usd(11900)}, {Product_ID: book, Amount: usd(11900)}}, usd(11900))
+	require.NoError(t, err)
+	assert.Equal(t, usd(950), breakdown.Lines[0].Tax)
+	assert.Equal(t, usd(950), breakdown.Lines[1].Tax)
+	assert.Equal(t, usd(5950), breakdown.Lines[0].Taxable)
+	assert.Equal(t, usd(1900), breakdown.Total)
+	assert.Equal(t, usd(0), breakdown.Exclusive)
+}
+func TestCalculateWithoutJurisdiction(t *testing.T) {
+	breakdown, err := Calculate([]models.TaxRule{{Country: "US", Rate: "10"}}, Jurisdiction{}, []Line{{Amount: usd(100)}}, money.Zero("USD"))
+	require.NoError(t, err)
+	assert.Equal(t, usd(0), breakdown.Total)
+	assert.Equal(t, "0", breakdown.Lines[0].Rate)
+}
+func TestParseRate(t *testing.T) {
+	for _, rate := range []string{"0", "8.875", "100"} {
+		_, err := ParseRate(rate)
+		assert.NoError(t, err, rate)
+	}
+	for _, rate := range []string{"-1", "100.5", "ten", ""} {
+		_, err := ParseRate(rate)
+		assert.ErrorIs(t, err, ErrInvalidRate, rate)
+	}
+}
@@ -0,0 +1,92 @@
+package taxonomy
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+var (
+	ErrCycle       = errors.New("category cannot be moved under itself or one of its descendants")
+	ErrInvalidSlug = errors.New("slug must contain at least one letter or digit")
+)
+func Slugify(value string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(value)) {
+		switch {
+		case r == '\'' || r == '’':
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+func AncestorsFor(parent *models.Category) []primitive.ObjectID {
+	if parent == nil {
+		return []primitive.ObjectID{}
+	}
+	ancestors := make([]primitive.ObjectID, 0, len(parent.Ancestors)+1)
+	ancestors = append(ancestors, parent.Ancestors...)
+	return append(ancestors, parent.ID)
+}
+func contains(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+func CheckMove(category models.Category, parent *models.Category) error {
+	if parent != nil && (parent.ID == category.ID || contains(parent.Ancestors, category.ID)) {
+		return ErrCycle
+	}
+	return nil
+}
+func Rebase(descendant models.Category, moved primitive.ObjectID, ancestors []primitive.ObjectID) []primitive.ObjectID {
+	rebased := make([]primitive.ObjectID, 0, len(ancestors)+len(descendant.Ancestors))
+	rebased = append(rebased, ancestors...)
+	rebased = append(rebased, moved)
+	for i, id := range descendant.Ancestors {
+		if id == moved {
+			return append(rebased, descendant.Ancestors[i+1:]...)
+		}
+	}
+	return descendant.Ancestors
+}
+func Tree(categories []models.Category) []models.CategoryNode {
+	children := make(map[primitive.ObjectID][]models.Category)
+	known := make(map[primitive.ObjectID]bool, len(categories))
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+	roots := make([]models.Category, 0)
+	for _, category := range categories {
+		if category.Parent_ID == nil || !known[*category.Parent_ID] {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.Parent_ID] = append(children[*category.Parent_ID], category)
+	}
+	var build func(level []models.Category) []models.CategoryNode
+	build = func(level []models.Category) []models.CategoryNode {
+		sort.SliceStable(level, func(i, j int) bool {
+			if level[i].Position != level[j].Position {
+				return level[i].Position < level[j].Position
+			}
+			return level[i].Name < level[j].Name
+		})
+		nodes := make([]models.CategoryNode, 0, len(level))
+		for _, category := range level {
+			nodes = append(nodes, models.CategoryNode{Category: category, Children: build(children[category.ID])})
+		}
+		return nodes
+	}
+	return build(roots)
+}
@@ -0,0 +1,48 @@
+package taxonomy
+import (
+	"testing"
+	"ecommerce/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func child(name string, parent *models.Category, position int) models.Category {
+	category := models.Category{ID: primitive.NewObjectID(), Name: name, Slug: Slugify(name), Position: position, Ancestors: AncestorsFor(parent)}
+	if parent != nil {
+		category.Parent_ID = &parent.ID
+	}
+	return category
+}
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "mens-shoes-sneakers", Slugify("  Men's Shoes & Sneakers! "))
+	assert.Equal(t, "tv-4k", Slugify("TV -- 4K"))
+	assert.Equal(t, "", Slugify("ÄÖÜ"))
+}
+func TestTreeOrdersChildren(t *testing.T) {
+	apparel := child("Apparel", nil, 1)
+	electronics := child("Electronics", nil, 0)
+	shirts := child("Shirts", &apparel, 0)
+	tees := child("Tees", &shirts, 0)
+	hats := child("Hats", &apparel, 0)
+	tree := Tree([]models.Category{tees, hats, apparel, shirts, electronics})
+	require.Len(t, tree, 2)
+	assert.Equal(t, "Electronics", tree[0].Name)
+	assert.Empty(t, tree[0].Children)
+	require.Len(t, tree[1].Children, 2)
+	assert.Equal(t, "Hats", tree[1].Children[0].Name)
+	assert.Equal(t, "Tees", tree[1].Children[1].Children[0].Name)
+	assert.Equal(t, []primitive.ObjectID{apparel.ID, shirts.ID}, tees.Ancestors)
+}
+func TestMoveSubtree(t *testing.T) {
+	apparel := child("Apparel", nil, 0)
+	shirts := child("Shirts", &apparel, 0)
+	tees := child("Tees", &shirts, 0)
+	sale := child("Sale", nil, 0)
+	assert.ErrorIs(t, CheckMove(apparel, &tees), ErrCycle)
+	assert.ErrorIs(t, CheckMove(shirts, &shirts), ErrCycle)
+	assert.NoError(t, CheckMove(shirts, &sale))
+	assert.NoError(t, CheckMove(shirts, nil))
+	newAncestors := AncestorsFor(&sale)
+	assert.Equal(t, []primitive.ObjectID{sale.ID, shirts.ID}, Rebase(tees, shirts.ID, newAncestors))
+	assert.Equal(t, []primitive.ObjectID{shirts.ID}, Rebase(tees, shirts.ID, AncestorsFor(nil)))
+}
@@ -0,0 +1,78 @@
+package token
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+const APIKeyPrefix = "ek_"
+var (
+	ErrAPIKeyInvalid = errors.New("api key is invalid")
+	ErrAPIKeyExpired = errors.New("api key is expired")
+)
+var APIKeyData *mongo.Collection
+func GenerateAPIKey() (key string, prefix string, hashedkey string, err error) {
+	b := make([]byte, 36)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(b[:4])
+	key = APIKeyPrefix + prefix + "_" + hex.EncodeToString(b[4:])
+	return key, prefix, HashOpaqueToken(key), nil
+}
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+func ValidateAPIKey(key string) (*models.APIKey, error) {
+	prefix, _, found := strings.Cut(strings.TrimPrefix(key, APIKeyPrefix), "_")
+	if !IsAPIKey(key) || !found || prefix == "" {
+		return nil, ErrAPIKeyInvalid
+	}
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var apikey models.APIKey
+	if err := APIKeyData.FindOne(ctx, bson.M{"prefix": prefix}).Decode(&apikey); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println(err)
+		}
+		return nil, ErrAPIKeyInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(apikey.Secret_Hash), []byte(HashOpaqueToken(key))) != 1 {
+		return nil, ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if apikey.Expires_At != nil && now.After(*apikey.Expires_At) {
+		return nil, ErrAPIKeyExpired
+	}
+	if apikey.Last_Used_At == nil || now.Sub(*apikey.Last_Used_At) > time.Minute {
+		if _, err := APIKeyData.UpdateOne(ctx, bson.M{"_id": apikey.ID}, bson.M{"$set": bson.M{"last_used_at": now}}); err != nil {
+			log.Println(err)
+		}
+		apikey.Last_Used_At = &now
+	}
+	return &apikey, nil
+}
+func APIKeyOwnerEmail(apikey *models.APIKey) (string, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var owner models.User
+	err := UserData.FindOne(ctx, bson.M{"user_id": apikey.User_ID, "anonymized_at": nil}, options.FindOne().SetProjection(bson.M{"email": 1})).Decode(&owner)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println(err)
+		}
+		return "", ErrAPIKeyInvalid
+	}
+	if owner.Email == nil {
+		return "", nil
+	}
+	return *owner.Email, nil
+}
@@ -0,0 +1,135 @@
+package token
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+func newTestClaims() *SignedDetails {
+	return &SignedDetails{
+		Uid: "123456",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+func TestKeyRingSignAndParse(t *testing.T) {
+	for _, alg := range []string{"RS256", "EdDSA"} {
+		key, err := GenerateSigningKey("k1", alg)
+		require.NoError(t, err)
+		ring := NewKeyRing()
+		ring.Add(key)
+		require.NoError(t, ring.SetActive("k1"))
+		signed, err := ring.Sign(newTestClaims())
+		require.NoError(t, err)
+		token, err := ring.Parse(signed, &SignedDetails{})
+		require.NoError(t, err, alg)
+		assert.Equal(t, "k1", token.Header["kid"])
+		assert.Equal(t, alg, token.Method.Alg())
+		assert.Equal(t, "123456", token.Claims.(*SignedDetails).Uid)
+	}
+}
+func TestKeyRingRotation(t *testing.T) {
+	oldkey, err := GenerateSigningKey("old", "EdDSA")
+	require.NoError(t, err)
+	newkey, err := GenerateSigningKey("new", "EdDSA")
+	require.NoError(t, err)
+	ring := NewKeyRing()
+	ring.Add(oldkey)
+	require.NoError(t, ring.SetActive("old"))
+	oldtoken, err := ring.Sign(newTestClaims())
+	require.NoError(t, err)
+	ring.Add(newkey)
+	require.NoError(t, ring.SetActive("new"))
+	newtoken, err := ring.Sign(newTestClaims())
+	require.NoError(t, err)
+	_, err = ring.Parse(oldtoken, &SignedDetails{})
+	assert.NoError(t, err)
+	_, err = ring.Parse(newtoken, &SignedDetails{})
+	assert.NoError(t, err)
+	assert.Error(t, ring.Retire("new"))
+	require.NoError(t, ring.Retire("old"))
+	_, err = ring.Parse(oldtoken, &SignedDetails{})
+	assert.Error(t, err)
+}
+func TestKeyRingRejectsUnexpectedAlgorithm(t *testing.T) {
+	key, err := GenerateSigningKey("k1", "RS256")
+	require.NoError(t, err)
+	ring := NewKeyRing()
+	ring.Add(key)
+	require.NoError(t, ring.SetActive("k1"))
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, newTestClaims())
+	hmacToken.Header["kid"] = "k1"
+	signed, err := hmacToken.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = ring.Parse(signed, &SignedDetails{})
+	assert.Error(t, err)
+	noneToken := jwt.NewWithClaims(jwt.SigningMethodNone, newTestClaims())
+	noneToken.Header["kid"] = "k1"
+	signed, err = noneToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = ring.Parse(signed, &SignedDetails{})
+	assert.Error(t, err)
+}
+func TestKeyRingJWKS(t *testing.T) {
+	rsakey, err := GenerateSigningKey("rsa", "RS256")
+	require.NoError(t, err)
+	edkey, err := GenerateSigningKey("ed", "EdDSA")
+	require.NoError(t, err)
+	ring := NewKeyRing()
+	ring.Add(rsakey)
+	ring.Add(edkey)
+	set := ring.JWKS()
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "ed", set.Keys[0].Kid)
+	assert.Equal(t, "OKP", set.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", set.Keys[0].Crv)
+	assert.Equal(t, "rsa", set.Keys[1].Kid)
+	assert.Equal(t, "RSA", set.Keys[1].Kty)
+	assert.Equal(t, "AQAB", set.Keys[1].E)
+}
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+	rsakey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "2026-01.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsakey))
+	_, edkey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edkey)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "2026-02.pem"), "PRIVATE KEY", der)
+	pubder, err := x509.MarshalPKIXPublicKey(edkey.Public())
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "2025-12.pub.pem"), "PUBLIC KEY", pubder)
+	ring, err := LoadKeyRing(dir, "2026-02", []string{"2026-01"})
+	require.NoError(t, err)
+	active, err := ring.Active()
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", active.Method.Alg())
+	_, ok := ring.Lookup("2026-01")
+	assert.False(t, ok)
+	_, ok = ring.Lookup("2025-12")
+	assert.True(t, ok)
+	_, err = LoadKeyRing(dir, "2025-12", nil)
+	assert.Error(t, err)
+}
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+}
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hashedkey, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.True(t, IsAPIKey(key))
+	assert.Contains(t, key, APIKeyPrefix+prefix+"_")
+	assert.Len(t, prefix, 8)
+	assert.Equal(t, HashOpaqueToken(key), hashedkey)
+	assert.NotContains(t, hashedkey, prefix)
+}
@@ -0,0 +1,18 @@
+package token
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+func GenerateOpaqueToken() (rawtoken string, hashedtoken string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	rawtoken = hex.EncodeToString(b)
+	return rawtoken, HashOpaqueToken(rawtoken), nil
+}
+func HashOpaqueToken(rawtoken string) string {
+	sum := sha256.Sum256([]byte(rawtoken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,122 @@
+package token
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+	"ecommerce/config"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+const (
+	PurposeTwoFactorChallenge = "2fa_challenge"
+	PurposeRefresh            = "refresh"
+)
+var (
+	ErrTokenMalformed        = errors.New("token is malformed")
+	ErrTokenSignatureInvalid = errors.New("token signature is invalid")
+	ErrTokenExpired          = errors.New("token is expired")
+	ErrTokenNotValidYet      = errors.New("token is not valid yet")
+	ErrTokenInvalidClaims    = errors.New("token has invalid claims")
+	ErrTokenWrongPurpose     = errors.New("token cannot be used for this purpose")
+)
+type Service struct {
+	Keys         *KeyRing
+	Issuer       string
+	Audience     string
+	Leeway       time.Duration
+	AccessTTL    time.Duration
+	RefreshTTL   time.Duration
+	ChallengeTTL time.Duration
+	Now          func() time.Time
+}
+func NewService(keys *KeyRing) *Service {
+	return &Service{
+		Keys:         keys,
+		Issuer:       config.String("JWT_ISSUER", "ecommerce"),
+		Audience:     config.String("JWT_AUDIENCE", "ecommerce-api"),
+		Leeway:       config.Duration("JWT_LEEWAY", 30*time.Second),
+		AccessTTL:    config.Duration("JWT_ACCESS_TTL", 24*time.Hour),
+		RefreshTTL:   config.Duration("JWT_REFRESH_TTL", 168*time.Hour),
+		ChallengeTTL: 5 * time.Minute,
+		Now:          time.Now,
+	}
+}
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+func (s *Service) Issue(details SignedDetails, ttl time.Duration) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := s.Now()
+	details.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    s.Issuer,
+		Subject:   details.Uid,
+		Audience:  jwt.ClaimStrings{s.Audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		ID:        jti,
+	}
+	return s.Keys.Sign(&details)
+}
+func (s *Service) Generate(email string, firstname string, lastname string, uid string, sid string) (signedtoken string, signedrefreshtoken string, err error) {
+	signedtoken, err = s.Issue(SignedDetails{Email: email, First_Name: firstname, Last_Name: lastname, Uid: uid, Session_ID: sid}, s.AccessTTL)
+	if err != nil {
+		return "", "", err
+	}
+	signedrefreshtoken, err = s.Issue(SignedDetails{Uid: uid, Session_ID: sid, Purpose: PurposeRefresh}, s.RefreshTTL)
+	if err != nil {
+		return "", "", err
+	}
+	return signedtoken, signedrefreshtoken, nil
+}
+func (s *Service) Challenge(uid string) (string, error) {
+	return s.Issue(SignedDetails{Uid: uid, Purpose: PurposeTwoFactorChallenge}, s.ChallengeTTL)
+}
+func (s *Service) Validate(signedtoken string) (*SignedDetails, error) {
+	claims := &SignedDetails{}
+	_, err := s.Keys.Parse(signedtoken, claims,
+		jwt.WithIssuer(s.Issuer),
+		jwt.WithAudience(s.Audience),
+		jwt.WithLeeway(s.Leeway),
+		jwt.WithTimeFunc(s.Now),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, classify(err)
+	}
+	if claims.Subject != claims.Uid {
+		return nil, ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+func (s *Service) ValidatePurpose(signedtoken string, purpose string) (*SignedDetails, error) {
+	claims, err := s.Validate(signedtoken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, ErrTokenWrongPurpose
+	}
+	return claims, nil
+}
+func classify(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ErrTokenMalformed
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return ErrTokenSignatureInvalid
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ErrTokenNotValidYet
+	}
+	return ErrTokenInvalidClaims
+}
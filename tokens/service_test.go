@@ -0,0 +1,88 @@
+package token
+import (
+	"testing"
+	"time"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+func newTestService(t *testing.T) *Service {
+	key, err := GenerateSigningKey("test", "EdDSA")
+	require.NoError(t, err)
+	ring := NewKeyRing()
+	ring.Add(key)
+	require.NoError(t, ring.SetActive("test"))
+	return &Service{
+		Keys:         ring,
+		Issuer:       "ecommerce",
+		Audience:     "ecommerce-api",
+		Leeway:       30 * time.Second,
+		AccessTTL:    time.Hour,
+		RefreshTTL:   24 * time.Hour,
+		ChallengeTTL: 5 * time.Minute,
+		Now:          time.Now,
+	}
+}
+func TestServiceSetsRegisteredClaims(t *testing.T) {
+	s := newTestService(t)
+	access, refresh, err := s.Generate("test@example.com", "John", "Doe", "123456", "session-1")
+	require.NoError(t, err)
+	claims, err := s.Validate(access)
+	require.NoError(t, err)
+	assert.Equal(t, "ecommerce", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"ecommerce-api"}, claims.Audience)
+	assert.Equal(t, "123456", claims.Subject)
+	assert.NotNil(t, claims.IssuedAt)
+	assert.NotNil(t, claims.NotBefore)
+	assert.NotEmpty(t, claims.ID)
+	assert.Empty(t, claims.Purpose)
+	refreshclaims, err := s.ValidatePurpose(refresh, PurposeRefresh)
+	require.NoError(t, err)
+	assert.NotEqual(t, claims.ID, refreshclaims.ID)
+	assert.Equal(t, "session-1", claims.Session_ID)
+	assert.Equal(t, "session-1", refreshclaims.Session_ID)
+	_, err = s.ValidatePurpose(access, PurposeRefresh)
+	assert.ErrorIs(t, err, ErrTokenWrongPurpose)
+}
+func TestServiceClockSkew(t *testing.T) {
+	s := newTestService(t)
+	issuer := newTestService(t)
+	issuer.Keys = s.Keys
+	issuer.Now = func() time.Time { return time.Now().Add(20 * time.Second) }
+	ahead, err := issuer.Challenge("123456")
+	require.NoError(t, err)
+	_, err = s.Validate(ahead)
+	assert.NoError(t, err)
+	issuer.Now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	farAhead, err := issuer.Challenge("123456")
+	require.NoError(t, err)
+	_, err = s.Validate(farAhead)
+	assert.ErrorIs(t, err, ErrTokenNotValidYet)
+	issuer.Now = func() time.Time { return time.Now().Add(-5*time.Minute - 20*time.Second) }
+	justExpired, err := issuer.Challenge("123456")
+	require.NoError(t, err)
+	_, err = s.Validate(justExpired)
+	assert.NoError(t, err)
+	issuer.Now = func() time.Time { return time.Now().Add(-time.Hour) }
+	expired, err := issuer.Challenge("123456")
+	require.NoError(t, err)
+	_, err = s.Validate(expired)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+func TestServiceTypedErrors(t *testing.T) {
+	s := newTestService(t)
+	_, err := s.Validate("not-a-token")
+	assert.ErrorIs(t, err, ErrTokenMalformed)
+	other := newTestService(t)
+	foreign, err := other.Challenge("123456")
+	require.NoError(t, err)
+	_, err = s.Validate(foreign)
+	assert.ErrorIs(t, err, ErrTokenSignatureInvalid)
+	wrongAudience := newTestService(t)
+	wrongAudience.Keys = s.Keys
+	wrongAudience.Audience = "another-service"
+	signed, err := wrongAudience.Challenge("123456")
+	require.NoError(t, err)
+	_, err = s.Validate(signed)
+	assert.ErrorIs(t, err, ErrTokenInvalidClaims)
+}
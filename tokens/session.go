@@ -0,0 +1,35 @@
+package token
+import (
+	"context"
+	"log"
+	"time"
+	"ecommerce/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+const sessionTouchInterval = time.Minute
+var SessionData *mongo.Collection
+func SessionActive(claims *SignedDetails) bool {
+	sid, err := primitive.ObjectIDFromHex(claims.Session_ID)
+	if err != nil {
+		return false
+	}
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	now := time.Now()
+	filter := bson.M{"_id": sid, "user_id": claims.Uid, "revoked_at": nil, "expires_at": bson.M{"$gt": now}}
+	var session models.Session
+	if err := SessionData.FindOne(ctx, filter).Decode(&session); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Println(err)
+		}
+		return false
+	}
+	if now.Sub(session.Last_Seen_At) > sessionTouchInterval {
+		if _, err := SessionData.UpdateOne(ctx, bson.M{"_id": sid}, bson.M{"$set": bson.M{"last_seen_at": now}}); err != nil {
+			log.Println(err)
+		}
+	}
+	return true
+}
@@ -4,76 +4,91 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 	"ecommerce/database"
+	"ecommerce/models"
 	"github.com/joho/godotenv"
-	jwt "github.com/dgrijalva/jwt-go"
+	jwt "github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 var UserData *mongo.Collection
-var SECRET_KEY string
+var Keys *KeyRing
+var DefaultService *Service
 func init() {
 	err := godotenv.Load("D:/CV-Projects/MainCV/CV-Ecommerce-Golang/.env")
 	if err != nil {
 		log.Fatal("Error loading .env file")
 	}
 	UserData = database.UserData(database.Client, "Users")
-	SECRET_KEY = os.Getenv("SECRET_LOVE")
+	APIKeyData = database.UserData(database.Client, "APIKeys")
+	SessionData = database.UserData(database.Client, "Sessions")
+	if Keys, err = loadKeyRing(); err != nil {
+		log.Fatal(err)
+	}
+	DefaultService = NewService(Keys)
+}
+func loadKeyRing() (*KeyRing, error) {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		return nil, ErrNoKeysDir
+	}
+	var retired []string
+	if kids := os.Getenv("JWT_RETIRED_KIDS"); kids != "" {
+		retired = strings.Split(kids, ",")
+	}
+	return LoadKeyRing(dir, os.Getenv("JWT_ACTIVE_KID"), retired)
 }
 type SignedDetails struct {
 	Email         string `json:"email"`
 	First_Name    string `json:"first_name"`
 	Last_Name     string `json:"last_name"`
 	Uid           string `json:"uid"`
-	jwt.StandardClaims
+	Purpose       string `json:"purpose,omitempty"`
+	Session_ID    string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
 }
 func TokenGenerator(email string, firstname string, lastname string, uid string) (signedtoken string, signedrefreshtoken string, err error) {
-	claims := &SignedDetails{
-		Email:      email,
-		First_Name: firstname,
-		Last_Name:  lastname,
-		Uid:        uid,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(24)).Unix(),
-		},
-	}
-	refreshclaims := &SignedDetails{
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(168)).Unix(),
-		},
-	}
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
-	if err != nil {
-		return "", "", err
+	return DefaultService.Generate(email, firstname, lastname, uid, "")
+}
+func SessionTokenGenerator(email string, firstname string, lastname string, uid string, sid string) (signedtoken string, signedrefreshtoken string, err error) {
+	return DefaultService.Generate(email, firstname, lastname, uid, sid)
+}
+func ValidateRefreshToken(signedtoken string) (*SignedDetails, error) {
+	return DefaultService.ValidatePurpose(signedtoken, PurposeRefresh)
+}
+func ChallengeGenerator(uid string) (signedtoken string, err error) {
+	return DefaultService.Challenge(uid)
+}
+func ValidateChallenge(signedtoken string) (*SignedDetails, error) {
+	return DefaultService.ValidatePurpose(signedtoken, PurposeTwoFactorChallenge)
+}
+func ValidateToken(signedtoken string) (*SignedDetails, error) {
+	return DefaultService.Validate(signedtoken)
+}
+func TokenRevoked(claims *SignedDetails) bool {
+	if claims.Session_ID != "" {
+		return !SessionActive(claims)
 	}
-	refreshtoken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshclaims).SignedString([]byte(SECRET_KEY))
-	if err != nil {
-		log.Panicln(err)
-		return
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var founduser models.User
+	opts := options.FindOne().SetProjection(bson.M{"tokens_revoked_at": 1})
+	err := UserData.FindOne(ctx, bson.M{"user_id": claims.Uid}, opts).Decode(&founduser)
+	if err == mongo.ErrNoDocuments {
+		return false
 	}
-	return token, refreshtoken, err
-}
-func ValidateToken(signedtoken string) (claims *SignedDetails, msg string) {
-	token, err := jwt.ParseWithClaims(signedtoken, &SignedDetails{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(SECRET_KEY), nil
-	})
 	if err != nil {
-		msg = err.Error()
-		return
+		log.Println(err)
+		return true
 	}
-	claims, ok := token.Claims.(*SignedDetails)
-	if !ok {
-		msg = "The Token is invalid"
-		return
-	}
-	if claims.ExpiresAt < time.Now().Local().Unix() {
-		msg = "token is expired"
-		return
+	if founduser.Tokens_Revoked_At == nil {
+		return false
 	}
-	return claims, msg
+	return claims.IssuedAt == nil || claims.IssuedAt.Unix() <= founduser.Tokens_Revoked_At.Unix()
 }
 func UpdateAllTokens(signedtoken string, signedrefreshtoken string, userid string) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -5,7 +5,7 @@ import (
 	"os"
 	"testing"
 	"time"
-	"github.com/dgrijalva/jwt-go"
+	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
@@ -25,7 +25,6 @@ func setup() {
 		log.Fatal("Error loading .env file")
 	}
 	mongoUri := os.Getenv("MONGO")
-	SECRET_KEY = os.Getenv("SECRET_LOVE") 
 	clientOptions := options.Client().ApplyURI(mongoUri)
 	client, err = mongo.Connect(ctx, clientOptions)
 	if err != nil {
@@ -69,24 +68,32 @@ func TestTokenValidation(t *testing.T) {
 	uid := "123456"
 	token, _, err := TokenGenerator(email, firstname, lastname, uid)
 	assert.NoError(t, err)
-	claims, msg := ValidateToken(token)
-	assert.Empty(t, msg)
+	claims, err := ValidateToken(token)
+	assert.NoError(t, err)
 	assert.NotNil(t, claims)
 	assert.Equal(t, email, claims.Email)
 	assert.Equal(t, firstname, claims.First_Name)
 	assert.Equal(t, lastname, claims.Last_Name)
 	assert.Equal(t, uid, claims.Uid)
+	assert.Equal(t, uid, claims.Subject)
+	assert.NotEmpty(t, claims.ID)
 	invalidToken := token + "invalid"
-	_, msg = ValidateToken(invalidToken)
-	assert.Equal(t, "signature is invalid", msg)
-	expiredToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &SignedDetails{
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(-time.Hour).Unix(),
+	_, err = ValidateToken(invalidToken)
+	assert.ErrorIs(t, err, ErrTokenSignatureInvalid)
+	tokenString, _ := Keys.Sign(&SignedDetails{
+		Uid: uid,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    DefaultService.Issuer,
+			Subject:   uid,
+			Audience:  jwt.ClaimStrings{DefaultService.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
 		},
 	})
-	tokenString, _ := expiredToken.SignedString([]byte(SECRET_KEY))
-	_, msg = ValidateToken(tokenString)
-	assert.Contains(t, msg, "token is expired")
+	_, err = ValidateToken(tokenString)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+	symmetricToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &SignedDetails{Uid: uid}).SignedString([]byte("secret"))
+	_, err = ValidateToken(symmetricToken)
+	assert.ErrorIs(t, err, ErrTokenSignatureInvalid)
 }
 func TestUpdateAllTokens(t *testing.T) {
 	setup()
@@ -0,0 +1,154 @@
+package variants
+import (
+	"errors"
+	"sort"
+	"strings"
+	"ecommerce/models"
+	"ecommerce/money"
+)
+var (
+	ErrVariantRequired = errors.New("product has variants, a sku or option combination is required")
+	ErrVariantNotFound = errors.New("no variant matches the selected options")
+	ErrNoVariants      = errors.New("product has no variants to select")
+	ErrInvalidOptions  = errors.New("product options must have unique names and values")
+	ErrInvalidVariant  = errors.New("each variant needs a unique sku and a valid, unique option combination")
+	ErrMissingPrice    = errors.New("variant has no price and the product has no base price")
+)
+type Selection struct {
+	SKU     string
+	Options map[string]string
+}
+func (s Selection) IsZero() bool {
+	return s.SKU == "" && len(s.Options) == 0
+}
+func Find(product models.Product, sku string) (models.Variant, bool) {
+	for _, variant := range product.Variants {
+		if variant.SKU == sku {
+			return variant, true
+		}
+	}
+	return models.Variant{}, false
+}
+func sameOptions(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if b[name] != value {
+			return false
+		}
+	}
+	return true
+}
+func Resolve(product models.Product, selection Selection) (*models.Variant, error) {
+	if len(product.Variants) == 0 {
+		if selection.IsZero() {
+			return nil, nil
+		}
+		return nil, ErrNoVariants
+	}
+	if selection.IsZero() {
+		return nil, ErrVariantRequired
+	}
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+		if selection.SKU != "" && variant.SKU != selection.SKU {
+			continue
+		}
+		if len(selection.Options) > 0 && !sameOptions(variant.Options, selection.Options) {
+			continue
+		}
+		return variant, nil
+	}
+	return nil, ErrVariantNotFound
+}
+func combination(options []models.ProductOption, selected map[string]string) string {
+	parts := make([]string, 0, len(options))
+	for _, option := range options {
+		parts = append(parts, option.Name+"="+selected[option.Name])
+	}
+	return strings.Join(parts, "&")
+}
+func Validate(product models.Product) error {
+	allowed := make(map[string]map[string]bool, len(product.Options))
+	for _, option := range product.Options {
+		name := strings.TrimSpace(option.Name)
+		if name == "" || allowed[name] != nil || len(option.Values) == 0 {
+			return ErrInvalidOptions
+		}
+		allowed[name] = make(map[string]bool, len(option.Values))
+		for _, value := range option.Values {
+			if strings.TrimSpace(value) == "" || allowed[name][value] {
+				return ErrInvalidOptions
+			}
+			allowed[name][value] = true
+		}
+	}
+	if len(product.Variants) > 0 && len(product.Options) == 0 {
+		return ErrInvalidVariant
+	}
+	skus := make(map[string]bool, len(product.Variants))
+	combinations := make(map[string]bool, len(product.Variants))
+	for _, variant := range product.Variants {
+		if strings.TrimSpace(variant.SKU) == "" || skus[variant.SKU] || len(variant.Options) != len(product.Options) {
+			return ErrInvalidVariant
+		}
+		for name, value := range variant.Options {
+			if !allowed[name][value] {
+				return ErrInvalidVariant
+			}
+		}
+		key := combination(product.Options, variant.Options)
+		if combinations[key] {
+			return ErrInvalidVariant
+		}
+		if variant.Price == nil && product.Price == nil {
+			return ErrMissingPrice
+		}
+		if variant.Stock != nil && *variant.Stock < 0 {
+			return ErrInvalidVariant
+		}
+		skus[variant.SKU] = true
+		combinations[key] = true
+	}
+	return nil
+}
+func SKUs(product models.Product) []string {
+	skus := make([]string, 0, len(product.Variants))
+	for _, variant := range product.Variants {
+		skus = append(skus, variant.SKU)
+	}
+	sort.Strings(skus)
+	return skus
+}
+func Price(product models.Product, variant *models.Variant) *money.Money {
+	if variant != nil && variant.Price != nil {
+		return variant.Price
+	}
+	return product.Price
+}
+func Stock(product models.Product, variant *models.Variant) *int64 {
+	if variant != nil {
+		return variant.Stock
+	}
+	return product.Stock
+}
+func Line(product models.Product, variant *models.Variant) (models.ProductUser, error) {
+	price := Price(product, variant)
+	if price == nil {
+		return models.ProductUser{}, ErrMissingPrice
+	}
+	line := models.ProductUser{Product_ID: product.Product_ID, Product_Name: product.Product_Name, Price: *price, Image: product.Image}
+	if product.Rating != nil {
+		rating := uint(*product.Rating)
+		line.Rating = &rating
+	}
+	if variant != nil {
+		line.Variant_SKU = variant.SKU
+		line.Variant_Options = variant.Options
+		if variant.Image != nil {
+			line.Image = variant.Image
+		}
+	}
+	return line, nil
+}
@@ -0,0 +1,95 @@
+package variants
+import (
+	"testing"
+	"ecommerce/models"
+	"ecommerce/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+func tee() models.Product {
+	name := "Tee"
+	base := money.New(2000, "USD")
+	large := money.New(2200, "USD")
+	image := "tee-red.png"
+	stock := int64(3)
+	return models.Product{
+		Product_ID:   primitive.NewObjectID(),
+		Product_Name: &name,
+		Price:        &base,
+		Options: []models.ProductOption{
+			{Name: "size", Values: []string{"M", "L"}},
+			{Name: "color", Values: []string{"red", "blue"}},
+		},
+		Variants: []models.Variant{
+			{SKU: "TEE-M-RED", Options: map[string]string{"size": "M", "color": "red"}, Image: &image, Stock: &stock, Barcode: "4006381333931"},
+			{SKU: "TEE-L-RED", Options: map[string]string{"size": "L", "color": "red"}, Price: &large},
+		},
+	}
+}
+func TestResolve(t *testing.T) {
+	product := tee()
+	variant, err := Resolve(product, Selection{SKU: "TEE-L-RED"})
+	require.NoError(t, err)
+	assert.Equal(t, "TEE-L-RED", variant.SKU)
+	variant, err = Resolve(product, Selection{Options: map[string]string{"color": "red", "size": "M"}})
+	require.NoError(t, err)
+	assert.Equal(t, "TEE-M-RED", variant.SKU)
+	_, err = Resolve(product, Selection{Options: map[string]string{"color": "blue", "size": "M"}})
+	assert.ErrorIs(t, err, ErrVariantNotFound)
+	_, err = Resolve(product, Selection{SKU: "TEE-L-RED", Options: map[string]string{"color": "red", "size": "M"}})
+	assert.ErrorIs(t, err, ErrVariantNotFound)
+	_, err = Resolve(product, Selection{})
+	assert.ErrorIs(t, err, ErrVariantRequired)
+	plain := models.Product{Product_ID: primitive.NewObjectID()}
+	variant, err = Resolve(plain, Selection{})
+	require.NoError(t, err)
+	assert.Nil(t, variant)
+	_, err = Resolve(plain, Selection{SKU: "TEE-L-RED"})
+	assert.ErrorIs(t, err, ErrNoVariants)
+}
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(tee()))
+	require.NoError(t, Validate(models.Product{}))
+	product := tee()
+	product.Options[1].Values = []string{"red", "red"}
+	assert.ErrorIs(t, Validate(product), ErrInvalidOptions)
+	product = tee()
+	product.Variants[1].SKU = "TEE-M-RED"
+	assert.ErrorIs(t, Validate(product), ErrInvalidVariant)
+	product = tee()
+	product.Variants[1].Options = map[string]string{"size": "M", "color": "red"}
+	assert.ErrorIs(t, Validate(product), ErrInvalidVariant)
+	product = tee()
+	product.Variants[1].Options = map[string]string{"size": "XL", "color": "red"}
+	assert.ErrorIs(t, Validate(product), ErrInvalidVariant)
+	product = tee()
+	product.Variants[1].Options = map[string]string{"size": "L"}
+	assert.ErrorIs(t, Validate(product), ErrInvalidVariant)
+	product = tee()
+	product.Price = nil
+	assert.ErrorIs(t, Validate(product), ErrMissingPrice)
+	product = tee()
+	product.Options = nil
+	assert.ErrorIs(t, Validate(product), ErrInvalidVariant)
+}
+func TestLine(t *testing.T) {
+	product := tee()
+	variant, err := Resolve(product, Selection{SKU: "TEE-M-RED"})
+	require.NoError(t, err)
+	line, err := Line(product, variant)
+	require.NoError(t, err)
+	assert.Equal(t, product.Product_ID, line.Product_ID)
+	assert.Equal(t, money.New(2000, "USD"), line.Price)
+	assert.Equal(t, "tee-red.png", *line.Image)
+	assert.Equal(t, "TEE-M-RED", line.Variant_SKU)
+	assert.Equal(t, map[string]string{"size": "M", "color": "red"}, line.Variant_Options)
+	assert.Equal(t, int64(3), *Stock(product, variant))
+	variant, err = Resolve(product, Selection{SKU: "TEE-L-RED"})
+	require.NoError(t, err)
+	assert.Equal(t, money.New(2200, "USD"), *Price(product, variant))
+	assert.Nil(t, Stock(product, variant))
+	assert.Equal(t, []string{"TEE-L-RED", "TEE-M-RED"}, SKUs(product))
+	_, err = Line(models.Product{}, nil)
+	assert.ErrorIs(t, err, ErrMissingPrice)
+}